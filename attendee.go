@@ -0,0 +1,65 @@
+package ics
+
+// Attendee represents a participant of an event (the ATTENDEE property)
+type Attendee struct {
+	email  string
+	name   string
+	role   string
+	status string
+	typ    string
+}
+
+// creates a new attendee
+func NewAttendee() *Attendee {
+	return new(Attendee)
+}
+
+// sets the attendee email
+func (a *Attendee) SetEmail(email string) {
+	a.email = email
+}
+
+// returns the attendee email
+func (a *Attendee) GetEmail() string {
+	return a.email
+}
+
+// sets the attendee name (CN parameter)
+func (a *Attendee) SetName(name string) {
+	a.name = name
+}
+
+// returns the attendee name
+func (a *Attendee) GetName() string {
+	return a.name
+}
+
+// sets the attendee role (ROLE parameter)
+func (a *Attendee) SetRole(role string) {
+	a.role = role
+}
+
+// returns the attendee role
+func (a *Attendee) GetRole() string {
+	return a.role
+}
+
+// sets the attendee status (PARTSTAT parameter)
+func (a *Attendee) SetStatus(status string) {
+	a.status = status
+}
+
+// returns the attendee status
+func (a *Attendee) GetStatus() string {
+	return a.status
+}
+
+// sets the attendee type (CUTYPE parameter)
+func (a *Attendee) SetType(typ string) {
+	a.typ = typ
+}
+
+// returns the attendee type
+func (a *Attendee) GetType() string {
+	return a.typ
+}
@@ -0,0 +1,273 @@
+// Package caldav implements the small subset of RFC 4791 (CalDAV) that
+// ics-golang needs to fetch calendars from a remote server: principal and
+// calendar-home discovery via PROPFIND, collection enumeration, and
+// VEVENT retrieval via a calendar-query REPORT.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimeRangeFilter narrows a calendar-query REPORT to VEVENTs that overlap
+// [Start, End). A zero value for either bound is omitted from the filter.
+type TimeRangeFilter struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Collection is a single calendar collection discovered under a
+// principal's calendar-home-set
+type Collection struct {
+	Href        string
+	DisplayName string
+}
+
+// Client speaks PROPFIND/REPORT against a single CalDAV server
+type Client struct {
+	endpoint string
+	username string
+	password string
+	http     *http.Client
+}
+
+// NewClient returns a CalDAV client authenticating with HTTP basic auth
+func NewClient(endpoint, username, password string) *Client {
+	return &Client{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{},
+	}
+}
+
+// DiscoverCalendars resolves the current-user-principal, then the
+// calendar-home-set for that principal, then enumerates the calendar
+// collections found there. Cancelling ctx aborts whichever PROPFIND
+// round-trip is in flight.
+func (c *Client) DiscoverCalendars(ctx context.Context) ([]Collection, error) {
+	principal, err := c.currentUserPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	homeSet, err := c.calendarHomeSet(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.calendarCollections(ctx, homeSet)
+}
+
+// FetchEvents issues a calendar-query REPORT against a collection and
+// returns the concatenated VEVENT iCal text wrapped in a VCALENDAR, ready
+// to hand to the ics package's Parser. Cancelling ctx aborts the REPORT
+// round-trip.
+func (c *Client) FetchEvents(ctx context.Context, collection Collection, filter *TimeRangeFilter) (string, error) {
+	body := calendarQueryBody(filter)
+
+	resp, err := c.request(ctx, "REPORT", collection.Href, body, map[string]string{"Depth": "1"})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	out.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	for _, r := range ms.Responses {
+		out.WriteString(r.Propstat.Prop.CalendarData)
+	}
+	out.WriteString("END:VCALENDAR\r\n")
+
+	return out.String(), nil
+}
+
+func (c *Client) currentUserPrincipal(ctx context.Context) (string, error) {
+	const body = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop><D:current-user-principal/></D:prop>
+</D:propfind>`
+
+	resp, err := c.request(ctx, "PROPFIND", "/", body, map[string]string{"Depth": "0"})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return "", err
+	}
+	if len(ms.Responses) == 0 {
+		return "", fmt.Errorf("caldav: no current-user-principal returned by %s", c.endpoint)
+	}
+	return ms.Responses[0].Propstat.Prop.CurrentUserPrincipal.Href, nil
+}
+
+func (c *Client) calendarHomeSet(ctx context.Context, principal string) (string, error) {
+	const body = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><C:calendar-home-set/></D:prop>
+</D:propfind>`
+
+	resp, err := c.request(ctx, "PROPFIND", principal, body, map[string]string{"Depth": "0"})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return "", err
+	}
+	if len(ms.Responses) == 0 {
+		return "", fmt.Errorf("caldav: no calendar-home-set returned for principal %s", principal)
+	}
+	return ms.Responses[0].Propstat.Prop.CalendarHomeSet.Href, nil
+}
+
+func (c *Client) calendarCollections(ctx context.Context, homeSet string) ([]Collection, error) {
+	const body = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <D:displayname/>
+    <D:resourcetype/>
+  </D:prop>
+</D:propfind>`
+
+	resp, err := c.request(ctx, "PROPFIND", homeSet, body, map[string]string{"Depth": "1"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	var collections []Collection
+	for _, r := range ms.Responses {
+		if !r.Propstat.Prop.ResourceType.IsCalendar() {
+			continue
+		}
+		collections = append(collections, Collection{
+			Href:        r.Href,
+			DisplayName: r.Propstat.Prop.DisplayName,
+		})
+	}
+	return collections, nil
+}
+
+func (c *Client) request(ctx context.Context, method, href, body string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.resolve(href), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 207 && resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("caldav: %s %s returned %d: %s", method, href, resp.StatusCode, respBody)
+	}
+	return resp, nil
+}
+
+func (c *Client) resolve(href string) string {
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href
+	}
+	return c.endpoint + href
+}
+
+func calendarQueryBody(filter *TimeRangeFilter) string {
+	timeRange := ""
+	if filter != nil {
+		var attrs []string
+		if !filter.Start.IsZero() {
+			attrs = append(attrs, fmt.Sprintf(`start="%s"`, filter.Start.UTC().Format("20060102T150405Z")))
+		}
+		if !filter.End.IsZero() {
+			attrs = append(attrs, fmt.Sprintf(`end="%s"`, filter.End.UTC().Format("20060102T150405Z")))
+		}
+		if len(attrs) > 0 {
+			timeRange = fmt.Sprintf("<C:time-range %s/>", strings.Join(attrs, " "))
+		}
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><C:calendar-data/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        %s
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`, timeRange)
+}
+
+// ----- minimal WebDAV multistatus XML model -----
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"response"`
+}
+
+type response struct {
+	Href     string   `xml:"href"`
+	Propstat propstat `xml:"propstat"`
+}
+
+type propstat struct {
+	Prop prop `xml:"prop"`
+}
+
+type prop struct {
+	CurrentUserPrincipal hrefValue    `xml:"current-user-principal"`
+	CalendarHomeSet      hrefValue    `xml:"calendar-home-set"`
+	DisplayName          string       `xml:"displayname"`
+	ResourceType         resourceType `xml:"resourcetype"`
+	CalendarData         string       `xml:"calendar-data"`
+}
+
+type hrefValue struct {
+	Href string `xml:"href"`
+}
+
+type resourceType struct {
+	Raw []xml.Name `xml:",any"`
+}
+
+// IsCalendar reports whether the resourcetype contains a <calendar/> element
+func (r resourceType) IsCalendar() bool {
+	for _, n := range r.Raw {
+		if n.Local == "calendar" {
+			return true
+		}
+	}
+	return false
+}
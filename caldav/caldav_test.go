@@ -0,0 +1,119 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer answers each PROPFIND/REPORT request with the multistatus
+// body that responses maps its request path to, or 404 for any other path.
+func newTestServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestDiscoverCalendars(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:">
+  <D:response>
+    <D:href>/principals/me/</D:href>
+    <D:propstat><D:prop><D:current-user-principal><D:href>/principals/me/</D:href></D:current-user-principal></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+		"/principals/me/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:response>
+    <D:href>/principals/me/</D:href>
+    <D:propstat><D:prop><C:calendar-home-set><D:href>/calendars/me/</D:href></C:calendar-home-set></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+		"/calendars/me/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:">
+  <D:response>
+    <D:href>/calendars/me/work/</D:href>
+    <D:propstat><D:prop>
+      <D:displayname>Work</D:displayname>
+      <D:resourcetype><D:collection/><C:calendar xmlns:C="urn:ietf:params:xml:ns:caldav"/></D:resourcetype>
+    </D:prop></D:propstat>
+  </D:response>
+  <D:response>
+    <D:href>/calendars/me/</D:href>
+    <D:propstat><D:prop>
+      <D:displayname>me</D:displayname>
+      <D:resourcetype><D:collection/></D:resourcetype>
+    </D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+	})
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "", "")
+	collections, err := client.DiscoverCalendars(context.Background())
+	if err != nil {
+		t.Fatalf("DiscoverCalendars: %v", err)
+	}
+
+	if len(collections) != 1 {
+		t.Fatalf("got %d collections, want 1 (the non-calendar home itself must be filtered out)", len(collections))
+	}
+	if collections[0].Href != "/calendars/me/work/" || collections[0].DisplayName != "Work" {
+		t.Errorf("got %+v, want Href=/calendars/me/work/ DisplayName=Work", collections[0])
+	}
+}
+
+func TestFetchEventsWrapsCalendarDataInVCALENDAR(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/calendars/me/work/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:response>
+    <D:href>/calendars/me/work/1.ics</D:href>
+    <D:propstat><D:prop><C:calendar-data>BEGIN:VEVENT
+UID:1
+END:VEVENT
+</C:calendar-data></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+	})
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "", "")
+	collection := Collection{Href: "/calendars/me/work/"}
+	filter := &TimeRangeFilter{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	got, err := client.FetchEvents(context.Background(), collection, filter)
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if !strings.HasPrefix(got, "BEGIN:VCALENDAR") || !strings.HasSuffix(got, "END:VCALENDAR\r\n") {
+		t.Fatalf("FetchEvents did not wrap the VEVENT in a VCALENDAR: %q", got)
+	}
+	if !strings.Contains(got, "UID:1") {
+		t.Fatalf("FetchEvents dropped the VEVENT body: %q", got)
+	}
+}
+
+func TestRequestErrorOnNonMultiStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("nope"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "", "")
+	if _, err := client.DiscoverCalendars(context.Background()); err == nil {
+		t.Fatal("expected an error for a non-207/200 response, got nil")
+	}
+}
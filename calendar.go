@@ -0,0 +1,108 @@
+package ics
+
+import "time"
+
+// SourceKind identifies where a Calendar's iCal content originated from
+type SourceKind string
+
+const (
+	SourceFile   SourceKind = "file"
+	SourceHTTP   SourceKind = "http"
+	SourceCalDAV SourceKind = "caldav"
+	SourceReader SourceKind = "reader"
+)
+
+// Source records the origin of a parsed Calendar: a local file, a plain
+// HTTP(S) link, a collection fetched from a CalDAV server, or an io.Reader
+type Source struct {
+	Kind SourceKind
+	Ref  string
+}
+
+// Calendar represents a parsed VCALENDAR component
+type Calendar struct {
+	name     string
+	desc     string
+	version  float64
+	timezone time.Location
+	events   []*Event
+	source   Source
+	method   string
+}
+
+// creates a new, empty calendar
+func NewCalendar() *Calendar {
+	c := new(Calendar)
+	c.events = []*Event{}
+	return c
+}
+
+// sets the calendar name (X-WR-CALNAME)
+func (c *Calendar) SetName(name string) {
+	c.name = name
+}
+
+// returns the calendar name
+func (c *Calendar) GetName() string {
+	return c.name
+}
+
+// sets the calendar description (X-WR-CALDESC)
+func (c *Calendar) SetDesc(desc string) {
+	c.desc = desc
+}
+
+// returns the calendar description
+func (c *Calendar) GetDesc() string {
+	return c.desc
+}
+
+// sets the calendar version (VERSION)
+func (c *Calendar) SetVersion(version float64) {
+	c.version = version
+}
+
+// returns the calendar version
+func (c *Calendar) GetVersion() float64 {
+	return c.version
+}
+
+// sets the calendar timezone
+func (c *Calendar) SetTimezone(timezone time.Location) {
+	c.timezone = timezone
+}
+
+// returns the calendar timezone
+func (c *Calendar) GetTimezone() time.Location {
+	return c.timezone
+}
+
+// sets the calendar source (where its iCal content was fetched from)
+func (c *Calendar) SetSource(source Source) {
+	c.source = source
+}
+
+// returns the calendar source
+func (c *Calendar) GetSource() Source {
+	return c.source
+}
+
+// sets the calendar's iTIP METHOD (e.g. REQUEST, REPLY, CANCEL)
+func (c *Calendar) SetMethod(method string) {
+	c.method = method
+}
+
+// returns the calendar's iTIP METHOD, or "" if none was set
+func (c *Calendar) GetMethod() string {
+	return c.method
+}
+
+// adds an event to the calendar
+func (c *Calendar) SetEvent(event Event) {
+	c.events = append(c.events, &event)
+}
+
+// returns all events in the calendar
+func (c *Calendar) GetEvents() []*Event {
+	return c.events
+}
@@ -0,0 +1,8 @@
+package ics
+
+// time layouts used when parsing iCal DATE-TIME and DATE values
+const (
+	IcsFormat         = "20060102T150405Z"
+	IcsFormatWholeDay = "20060102"
+	IcsFormatLocal    = "20060102T150405"
+)
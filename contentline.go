@@ -0,0 +1,219 @@
+package ics
+
+import "strings"
+
+// Property is a single parsed iCal content line: its name, any
+// parameters (each parameter may carry several comma-separated values),
+// and its unescaped value.
+type Property struct {
+	Name   string
+	Params map[string][]string
+	Value  string
+}
+
+// Param returns the first value of the named parameter, or "" if the
+// parameter is absent
+func (p Property) Param(name string) string {
+	if values := p.Params[strings.ToUpper(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Component is a BEGIN/END nested block, e.g. VCALENDAR, VEVENT, VALARM
+// or VTIMEZONE's STANDARD/DAYLIGHT sub-components
+type Component struct {
+	Name       string
+	Properties []Property
+	Children   []*Component
+}
+
+// Get returns the first property with the given name
+func (c *Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// All returns every property with the given name, in document order
+func (c *Component) All(name string) []Property {
+	var out []Property
+	for _, p := range c.Properties {
+		if p.Name == name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ChildrenOf returns the direct child components with the given name
+func (c *Component) ChildrenOf(name string) []*Component {
+	var out []*Component
+	for _, child := range c.Children {
+		if child.Name == name {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// propValue is a shorthand for the Value of a component's first property
+// with the given name, or "" if it has none
+func propValue(c *Component, name string) string {
+	if p, ok := c.Get(name); ok {
+		return p.Value
+	}
+	return ""
+}
+
+// parseComponentTree unfolds and tokenizes raw iCal text, then drives a
+// component stack for BEGIN/END nesting, so VALARM, VTIMEZONE and nested
+// VEVENT/VTODO blocks are parsed structurally rather than via a single
+// BEGIN:VEVENT...END:VEVENT regex. The returned Component is a synthetic
+// root whose children are the top-level components (normally a single
+// VCALENDAR).
+func parseComponentTree(raw string) *Component {
+	root := &Component{Name: "ROOT"}
+	stack := []*Component{root}
+
+	for _, line := range unfoldLines(raw) {
+		prop := parseProperty(line)
+		current := stack[len(stack)-1]
+
+		switch prop.Name {
+		case "BEGIN":
+			child := &Component{Name: prop.Value}
+			current.Children = append(current.Children, child)
+			stack = append(stack, child)
+		case "END":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	return root
+}
+
+// unfoldLines joins RFC 5545 §3.1 folded content lines: a CRLF (or a bare
+// LF, for leniency) immediately followed by a space or tab continues the
+// previous line. Blank lines are dropped.
+func unfoldLines(raw string) []string {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	rawLines := strings.Split(raw, "\n")
+
+	var lines []string
+	for _, line := range rawLines {
+		if len(lines) > 0 && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseProperty tokenizes a single unfolded content line into its Name,
+// Params and Value, honouring DQUOTE-quoted and comma-separated
+// parameter values in any order, and unescaping \\, \,, \; and \n in the
+// value itself.
+func parseProperty(line string) Property {
+	name, rest := splitNameAndRest(line)
+	prop := Property{Name: strings.ToUpper(name), Params: map[string][]string{}}
+
+	for len(rest) > 0 {
+		if rest[0] == ':' {
+			prop.Value = unescapeValue(rest[1:])
+			break
+		}
+		if rest[0] != ';' {
+			break
+		}
+		rest = rest[1:]
+
+		key, values, remainder := parseParam(rest)
+		if key != "" {
+			prop.Params[strings.ToUpper(key)] = values
+		}
+		rest = remainder
+	}
+
+	return prop
+}
+
+// splitNameAndRest splits a content line into its property name and
+// everything from the first ':' or ';' onward
+func splitNameAndRest(line string) (name string, rest string) {
+	for i, r := range line {
+		if r == ':' || r == ';' {
+			return line[:i], line[i:]
+		}
+	}
+	return line, ""
+}
+
+// parseParam consumes a single "KEY=value1,value2" segment (values may
+// be DQUOTE-quoted) from the head of rest and returns whatever text is
+// left, starting at the next ';' or ':'
+func parseParam(rest string) (key string, values []string, remainder string) {
+	eq := strings.IndexByte(rest, '=')
+	if eq < 0 {
+		return "", nil, ""
+	}
+	key = rest[:eq]
+	rest = rest[eq+1:]
+
+	for {
+		var value string
+		if len(rest) > 0 && rest[0] == '"' {
+			if end := strings.IndexByte(rest[1:], '"'); end >= 0 {
+				value, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				value, rest = rest[1:], ""
+			}
+		} else if end := strings.IndexAny(rest, ",;:"); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		values = append(values, value)
+
+		if len(rest) > 0 && rest[0] == ',' {
+			rest = rest[1:]
+			continue
+		}
+		break
+	}
+
+	return key, values, rest
+}
+
+// unescapeValue reverses the TEXT escaping of RFC 5545 §3.3.11: \\, \,,
+// \; and \n/\N become their literal characters
+func unescapeValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			switch value[i+1] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			case ',', ';', '\\':
+				b.WriteByte(value[i+1])
+			default:
+				b.WriteByte(value[i+1])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package ics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnfoldLines(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "no folding",
+			in:   "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n",
+			want: []string{"BEGIN:VCALENDAR", "END:VCALENDAR"},
+		},
+		{
+			// the folding WSP is inserted, not borrowed from the content, so
+			// unfolding drops it entirely rather than collapsing to a space
+			name: "space continuation",
+			in:   "SUMMARY:a long \r\n summary\r\n",
+			want: []string{"SUMMARY:a long summary"},
+		},
+		{
+			name: "tab continuation",
+			in:   "SUMMARY:a long \r\n\tsummary\r\n",
+			want: []string{"SUMMARY:a long summary"},
+		},
+		{
+			name: "bare LF leniency",
+			in:   "SUMMARY:a long \n summary\n",
+			want: []string{"SUMMARY:a long summary"},
+		},
+		{
+			name: "blank lines dropped",
+			in:   "BEGIN:VEVENT\r\n\r\nEND:VEVENT\r\n",
+			want: []string{"BEGIN:VEVENT", "END:VEVENT"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := unfoldLines(c.in)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("unfoldLines(%q) = %#v, want %#v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseProperty(t *testing.T) {
+	cases := []struct {
+		name       string
+		line       string
+		wantName   string
+		wantValue  string
+		wantParams map[string][]string
+	}{
+		{
+			name:       "no params",
+			line:       "SUMMARY:Team meeting",
+			wantName:   "SUMMARY",
+			wantValue:  "Team meeting",
+			wantParams: map[string][]string{},
+		},
+		{
+			name:      "single param",
+			line:      "DTSTART;TZID=Europe/Berlin:20240115T090000",
+			wantName:  "DTSTART",
+			wantValue: "20240115T090000",
+			wantParams: map[string][]string{
+				"TZID": {"Europe/Berlin"},
+			},
+		},
+		{
+			name:      "quoted param value",
+			line:      `ATTENDEE;CN="Doe, Jane":mailto:jane@example.com`,
+			wantName:  "ATTENDEE",
+			wantValue: "mailto:jane@example.com",
+			wantParams: map[string][]string{
+				"CN": {"Doe, Jane"},
+			},
+		},
+		{
+			name:      "multiple params in any order",
+			line:      "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=ACCEPTED;CN=John:mailto:john@example.com",
+			wantName:  "ATTENDEE",
+			wantValue: "mailto:john@example.com",
+			wantParams: map[string][]string{
+				"ROLE":     {"REQ-PARTICIPANT"},
+				"PARTSTAT": {"ACCEPTED"},
+				"CN":       {"John"},
+			},
+		},
+		{
+			name:      "comma separated param values",
+			line:      `ATTENDEE;DELEGATED-FROM="mailto:a@example.com","mailto:b@example.com":mailto:c@example.com`,
+			wantName:  "ATTENDEE",
+			wantValue: "mailto:c@example.com",
+			wantParams: map[string][]string{
+				"DELEGATED-FROM": {"mailto:a@example.com", "mailto:b@example.com"},
+			},
+		},
+		{
+			name:      "escaped value",
+			line:      `DESCRIPTION:Line one\nLine two\, with a comma`,
+			wantName:  "DESCRIPTION",
+			wantValue: "Line one\nLine two, with a comma",
+			wantParams: map[string][]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := parseProperty(c.line)
+			if got.Name != c.wantName {
+				t.Errorf("Name = %q, want %q", got.Name, c.wantName)
+			}
+			if got.Value != c.wantValue {
+				t.Errorf("Value = %q, want %q", got.Value, c.wantValue)
+			}
+			if !reflect.DeepEqual(got.Params, c.wantParams) {
+				t.Errorf("Params = %#v, want %#v", got.Params, c.wantParams)
+			}
+		})
+	}
+}
+
+func TestParseComponentTreeNesting(t *testing.T) {
+	raw := `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:STANDARD
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:1
+SUMMARY:First
+END:VEVENT
+BEGIN:VEVENT
+UID:2
+SUMMARY:Second
+END:VEVENT
+END:VCALENDAR
+`
+	root := parseComponentTree(raw)
+	vcalendars := root.ChildrenOf("VCALENDAR")
+	if len(vcalendars) != 1 {
+		t.Fatalf("got %d VCALENDAR components, want 1", len(vcalendars))
+	}
+	vcalendar := vcalendars[0]
+
+	if propValue(vcalendar, "VERSION") != "2.0" {
+		t.Errorf("VERSION = %q, want 2.0", propValue(vcalendar, "VERSION"))
+	}
+
+	events := vcalendar.ChildrenOf("VEVENT")
+	if len(events) != 2 {
+		t.Fatalf("got %d VEVENT components, want 2", len(events))
+	}
+	if propValue(events[0], "SUMMARY") != "First" {
+		t.Errorf("first event SUMMARY = %q, want First", propValue(events[0], "SUMMARY"))
+	}
+	if propValue(events[1], "SUMMARY") != "Second" {
+		t.Errorf("second event SUMMARY = %q, want Second", propValue(events[1], "SUMMARY"))
+	}
+
+	tzs := vcalendar.ChildrenOf("VTIMEZONE")
+	if len(tzs) != 1 {
+		t.Fatalf("got %d VTIMEZONE components, want 1", len(tzs))
+	}
+	standards := tzs[0].ChildrenOf("STANDARD")
+	if len(standards) != 1 || propValue(standards[0], "TZOFFSETTO") != "+0100" {
+		t.Fatalf("STANDARD sub-component not nested correctly under VTIMEZONE")
+	}
+}
+
+func TestComponentAllReturnsEveryMatch(t *testing.T) {
+	raw := "BEGIN:VEVENT\r\nATTENDEE:mailto:a@example.com\r\nATTENDEE:mailto:b@example.com\r\nEND:VEVENT\r\n"
+	root := parseComponentTree(raw)
+	event := root.ChildrenOf("VEVENT")[0]
+
+	attendees := event.All("ATTENDEE")
+	if len(attendees) != 2 {
+		t.Fatalf("got %d ATTENDEE properties, want 2", len(attendees))
+	}
+	if attendees[0].Value != "mailto:a@example.com" || attendees[1].Value != "mailto:b@example.com" {
+		t.Errorf("unexpected ATTENDEE values: %#v", attendees)
+	}
+}
@@ -0,0 +1,213 @@
+package ics
+
+import (
+	"time"
+)
+
+// Event represents a single VEVENT component
+type Event struct {
+	id            string
+	importedID    string
+	status        string
+	summary       string
+	description   string
+	class         string
+	sequence      int
+	created       time.Time
+	lastModified  time.Time
+	rrule         string
+	start         time.Time
+	end           time.Time
+	wholeDayEvent bool
+	attendees     []Attendee
+	calendar      *Calendar
+
+	exdate       []time.Time
+	rdate        []time.Time
+	recurrenceID string
+	floating     bool
+	organizer    string
+
+	occurrenceGen *recurrenceGenerator
+}
+
+// creates a new, empty event
+func NewEvent() *Event {
+	return new(Event)
+}
+
+func (e *Event) SetID(id string) {
+	e.id = id
+}
+
+func (e *Event) GetID() string {
+	return e.id
+}
+
+func (e *Event) SetImportedID(id string) {
+	e.importedID = id
+}
+
+func (e *Event) GetImportedID() string {
+	return e.importedID
+}
+
+func (e *Event) SetStatus(status string) {
+	e.status = status
+}
+
+func (e *Event) GetStatus() string {
+	return e.status
+}
+
+func (e *Event) SetSummary(summary string) {
+	e.summary = summary
+}
+
+func (e *Event) GetSummary() string {
+	return e.summary
+}
+
+func (e *Event) SetDescription(description string) {
+	e.description = description
+}
+
+func (e *Event) GetDescription() string {
+	return e.description
+}
+
+func (e *Event) SetClass(class string) {
+	e.class = class
+}
+
+func (e *Event) GetClass() string {
+	return e.class
+}
+
+func (e *Event) SetSequence(sequence int) {
+	e.sequence = sequence
+}
+
+func (e *Event) GetSequence() int {
+	return e.sequence
+}
+
+func (e *Event) SetCreated(created time.Time) {
+	e.created = created
+}
+
+func (e *Event) GetCreated() time.Time {
+	return e.created
+}
+
+func (e *Event) SetLastModified(modified time.Time) {
+	e.lastModified = modified
+}
+
+func (e *Event) GetLastModified() time.Time {
+	return e.lastModified
+}
+
+func (e *Event) SetRRule(rrule string) {
+	e.rrule = rrule
+}
+
+func (e *Event) GetRRule() string {
+	return e.rrule
+}
+
+func (e *Event) SetStart(start time.Time) {
+	e.start = start
+}
+
+func (e *Event) GetStart() time.Time {
+	return e.start
+}
+
+func (e *Event) SetEnd(end time.Time) {
+	e.end = end
+}
+
+func (e *Event) GetEnd() time.Time {
+	return e.end
+}
+
+func (e *Event) SetWholeDayEvent(wholeDay bool) {
+	e.wholeDayEvent = wholeDay
+}
+
+func (e *Event) GetWholeDayEvent() bool {
+	return e.wholeDayEvent
+}
+
+func (e *Event) SetAttendees(attendees []Attendee) {
+	e.attendees = attendees
+}
+
+func (e *Event) GetAttendees() []Attendee {
+	return e.attendees
+}
+
+func (e *Event) SetCalendar(calendar *Calendar) {
+	e.calendar = calendar
+}
+
+func (e *Event) GetCalendar() *Calendar {
+	return e.calendar
+}
+
+func (e *Event) SetExdate(exdate []time.Time) {
+	e.exdate = exdate
+}
+
+func (e *Event) GetExdate() []time.Time {
+	return e.exdate
+}
+
+func (e *Event) SetRdate(rdate []time.Time) {
+	e.rdate = rdate
+}
+
+func (e *Event) GetRdate() []time.Time {
+	return e.rdate
+}
+
+func (e *Event) SetRecurrenceID(recurrenceID string) {
+	e.recurrenceID = recurrenceID
+}
+
+func (e *Event) GetRecurrenceID() string {
+	return e.recurrenceID
+}
+
+// SetFloating marks the event's Start/End as a "floating" local time, i.e.
+// one with no TZID and no trailing Z, per RFC 5545 §3.3.5
+func (e *Event) SetFloating(floating bool) {
+	e.floating = floating
+}
+
+// Floating reports whether Start/End are floating local times rather than
+// being anchored to a specific zone or UTC
+func (e *Event) Floating() bool {
+	return e.floating
+}
+
+// sets the event organizer (ORGANIZER property, typically a mailto: URI)
+func (e *Event) SetOrganizer(organizer string) {
+	e.organizer = organizer
+}
+
+// returns the event organizer
+func (e *Event) GetOrganizer() string {
+	return e.organizer
+}
+
+// returns a shallow copy of the event, suitable for further mutation
+// (e.g. shifting Start/End when generating a recurrence instance)
+func (e *Event) clone() *Event {
+	clone := *e
+	attendees := make([]Attendee, len(e.attendees))
+	copy(attendees, e.attendees)
+	clone.attendees = attendees
+	return &clone
+}
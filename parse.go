@@ -1,465 +1,476 @@
 package ics
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-)
 
-func init() {
-	mutex = new(sync.Mutex)
-}
+	"github.com/karlpilkington/ics-golang/caldav"
+)
 
+var httpPrefix = regexp.MustCompile(`^https?://`)
+
+// Options configures a Parser
+type Options struct {
+	// Concurrency bounds how many sources (Parse/ParseCalDAV calls) are
+	// fetched and parsed at once; extra calls return at once, and their
+	// work waits until a slot frees up. Defaults to 4.
+	Concurrency int
+	// HTTPClient fetches http(s) sources. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Parser fetches and parses iCal sources - local files, HTTP(S) links,
+// CalDAV collections, or raw io.Readers - into streams of *Event.
+//
+// Unlike the original goroutine-per-link design, a Parser has no
+// unbounded background loop: every Parse/ParseCalDAV call is bounded by
+// ctx and by the parser's own Concurrency, and Close stops and drains
+// every in-flight call.
 type Parser struct {
-	inputChan       chan string
-	outputChan      chan *Event
-	bufferedChan    chan *Event
-	errChan         chan error
-	parsedCalendars []*Calendar
-	parsedEvents    []*Event
-	statusCalendars int
-	wg              *sync.WaitGroup
-}
-
-// creates new parser
-func New() *Parser {
-	p := new(Parser)
-	p.inputChan = make(chan string)
-	p.outputChan = make(chan *Event)
-	p.bufferedChan = make(chan *Event)
-	p.errChan = make(chan error)
-	p.wg = new(sync.WaitGroup)
-	p.parsedCalendars = []*Calendar{}
-	p.parsedEvents = []*Event{}
-
-	// buffers the events output chan
-	go func() {
-		for {
-			if len(p.parsedEvents) > 0 {
-				select {
-				case p.outputChan <- p.parsedEvents[0]:
-					p.parsedEvents = p.parsedEvents[1:]
-				case event := <-p.bufferedChan:
-					p.parsedEvents = append(p.parsedEvents, event)
-				}
-			} else {
-				event := <-p.bufferedChan
-				p.parsedEvents = append(p.parsedEvents, event)
-			}
-		}
-	}()
+	opts Options
 
-	go func(input chan string) {
-		// endless loop for getting the ics urls
-		for {
-			link := <-input
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
 
-			// mark calendar in the wait group as not parsed
-			p.wg.Add(1)
-
-			// marks that we have statusCalendars +1 calendars to be parsed
-			mutex.Lock()
-			p.statusCalendars++
-			mutex.Unlock()
-
-			go func(link string) {
-				// mark calendar in the wait group as  parsed
-				defer p.wg.Done()
+	idMu      sync.Mutex
+	idCounter int
+}
 
-				iCalContent, err := p.getICal(link)
-				if err != nil {
-					p.errChan <- err
+// NewParser returns a Parser bounded by ctx: cancelling ctx (or calling
+// Close) stops every in-flight Parse/ParseCalDAV call.
+func NewParser(ctx context.Context, opts Options) *Parser {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
 
-					mutex.Lock()
-					// marks that we have parsed 1 calendar and we have statusCalendars -1 left to be parsed
-					p.statusCalendars--
-					mutex.Unlock()
+	ctx, cancel := context.WithCancel(ctx)
+	return &Parser{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, opts.Concurrency),
+	}
+}
 
-					return
-				}
+// Close stops every in-flight Parse/ParseCalDAV call and waits for their
+// goroutines to exit. The Parser must not be used afterwards.
+func (p *Parser) Close() {
+	p.cancel()
+	p.wg.Wait()
+}
 
-				// parse the ICal calendar
-				p.parseICalContent(iCalContent)
+// Parse fetches and parses a single source - a local file path, an
+// HTTP(S) URL (both as a string), or an io.Reader already positioned at
+// iCal text - and streams its events and any fetch/parse error on the
+// returned channels. Both channels are closed once the source is fully
+// parsed, ctx is done, or the Parser is closed. The event channel is
+// unbuffered, so a slow consumer holds back the sending of further
+// events; the source itself is read in full and parsed before the first
+// event is sent.
+func (p *Parser) Parse(ctx context.Context, source interface{}) (<-chan *Event, <-chan error) {
+	events := make(chan *Event)
+	errs := make(chan error, 1)
 
-				mutex.Lock()
-				// marks that we have parsed 1 calendar and we have statusCalendars -1 left to be parsed
-				p.statusCalendars--
-				mutex.Unlock()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(events)
+		defer close(errs)
 
-			}(link)
+		if !p.acquire(ctx, errs) {
+			return
 		}
-	}(p.inputChan)
-	// p.wg.Wait()
-	// return p.inputChan
-	return p
-}
-
-//  returns the chan for calendar urls
-func (p *Parser) GetInputChan() chan string {
-	return p.inputChan
-}
+		defer p.release()
 
-// returns the chan where will be received events
-func (p *Parser) GetOutputChan() chan *Event {
-	return p.outputChan
-}
-
-// returns the chan where will be received events
-func (p *Parser) GetCalendars() ([]*Calendar, error) {
-	if !p.Done() {
-		return nil, errors.New("Calendars not parsed")
-	}
-	return p.parsedCalendars, nil
-}
+		content, src, err := p.readSource(ctx, source)
+		if err != nil {
+			errs <- err
+			return
+		}
 
-// is everything is parsed
-func (p *Parser) Done() bool {
-	return p.statusCalendars == 0
-}
+		cal := p.buildCalendar(content, src)
+		p.emit(ctx, cal.GetEvents(), events)
+	}()
 
-// wait until everything is parsed
-func (p *Parser) Wait() {
-	p.wg.Wait()
+	return events, errs
 }
 
-//  get the data from the calendar
-func (p *Parser) getICal(url string) (string, error) {
-	re, _ := regexp.Compile(`http(s){0,1}:\/\/`)
+// ParseCalDAV discovers the calendars exposed by a CalDAV server under
+// endpoint and streams their events the same way Parse does. filter may
+// be nil to fetch the full collections.
+func (p *Parser) ParseCalDAV(ctx context.Context, endpoint, username, password string, filter *caldav.TimeRangeFilter) (<-chan *Event, <-chan error) {
+	events := make(chan *Event)
+	errs := make(chan error, 1)
 
-	var fileName string
-	var errDownload error
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		// A per-collection error must never be silently dropped just
+		// because errs's buffer is full and the caller hasn't drained it
+		// yet (the natural usage drains events to completion first). Each
+		// error gets its own delivery goroutine instead of a best-effort
+		// buffered send, and errs only closes once every one of them has
+		// either delivered or given up (ctx/parser done). events must
+		// still close as soon as the fetch loop below finishes, without
+		// waiting on errs - it's deferred after (so it runs before) the
+		// errWg wait, or a caller draining events fully before touching
+		// errs would deadlock against the pending error sends.
+		var errWg sync.WaitGroup
+		defer func() {
+			errWg.Wait()
+			close(errs)
+		}()
+		defer close(events)
+		report := func(err error) {
+			errWg.Add(1)
+			go func() {
+				defer errWg.Done()
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+				case <-p.ctx.Done():
+				}
+			}()
+		}
 
-	if re.FindString(url) != "" {
-		// download the file and store it local
-		fileName, errDownload = downloadFromUrl(url)
+		if !p.acquire(ctx, errs) {
+			return
+		}
+		defer p.release()
 
-		if errDownload != nil {
-			return "", errDownload
+		client := caldav.NewClient(endpoint, username, password)
+		collections, err := client.DiscoverCalendars(ctx)
+		if err != nil {
+			report(err)
+			return
 		}
 
-	} else { //  use a file from local storage
+		for _, collection := range collections {
+			content, err := client.FetchEvents(ctx, collection, filter)
+			if err != nil {
+				report(err)
+				continue
+			}
 
-		//  check if file exists
-		if fileExists(url) {
-			fileName = url
-		} else {
-			err := fmt.Sprintf("File %s does not exists", url)
-			return "", errors.New(err)
+			cal := p.buildCalendar(content, Source{Kind: SourceCalDAV, Ref: collection.Href})
+			if !p.emit(ctx, cal.GetEvents(), events) {
+				return
+			}
 		}
-	}
-
-	//  read the file with the ical data
-	fileContent, errReadFile := ioutil.ReadFile(fileName)
+	}()
 
-	if errReadFile != nil {
-		return "", errReadFile
+	return events, errs
+}
+
+// acquire blocks until a concurrency slot is free, ctx is done, or the
+// parser is closed, reporting which on the errs channel
+func (p *Parser) acquire(ctx context.Context, errs chan<- error) bool {
+	select {
+	case p.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		errs <- ctx.Err()
+		return false
+	case <-p.ctx.Done():
+		errs <- p.ctx.Err()
+		return false
 	}
-
-	return fmt.Sprintf("%s", fileContent), nil
 }
 
-// ======================== CALENDAR PARSING ===================
-
-// parses the iCal formated string to a calendar object
-func (p *Parser) parseICalContent(iCalContent string) {
-	ical := NewCalendar()
-	p.parsedCalendars = append(p.parsedCalendars, ical)
+func (p *Parser) release() {
+	<-p.sem
+}
 
-	// split the data into calendar info and events data
-	eventsData, calInfo := explodeICal(iCalContent)
-	idCounter++
+// emit streams events on the output channel, stopping early (and
+// reporting false) if ctx is done or the parser is closed
+func (p *Parser) emit(ctx context.Context, batch []*Event, out chan<- *Event) bool {
+	for _, event := range batch {
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return false
+		case <-p.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// readSource fetches the raw iCal text for a file path, an http(s) URL,
+// or an io.Reader, classifying it with a Source for the resulting
+// Calendar
+func (p *Parser) readSource(ctx context.Context, source interface{}) (string, Source, error) {
+	switch v := source.(type) {
+	case io.Reader:
+		data, err := ioutil.ReadAll(v)
+		if err != nil {
+			return "", Source{}, err
+		}
+		return string(data), Source{Kind: SourceReader}, nil
 
-	// fill the calendar fields
-	ical.SetName(p.parseICalName(calInfo))
-	ical.SetDesc(p.parseICalDesc(calInfo))
-	ical.SetVersion(p.parseICalVersion(calInfo))
-	ical.SetTimezone(p.parseICalTimezone(calInfo))
+	case string:
+		if httpPrefix.MatchString(v) {
+			data, err := p.fetchHTTP(ctx, v)
+			if err != nil {
+				return "", Source{}, err
+			}
+			return data, Source{Kind: SourceHTTP, Ref: v}, nil
+		}
 
-	// parse the events and add them to ical
-	p.parseEvents(ical, eventsData)
+		if !fileExists(v) {
+			return "", Source{}, fmt.Errorf("ics: file %s does not exist", v)
+		}
+		data, err := ioutil.ReadFile(v)
+		if err != nil {
+			return "", Source{}, err
+		}
+		return string(data), Source{Kind: SourceFile, Ref: v}, nil
 
+	default:
+		return "", Source{}, fmt.Errorf("ics: unsupported source type %T", source)
+	}
 }
 
-// explodes the ICal content to array of events and calendar info
-func explodeICal(iCalContent string) ([]string, string) {
-	reEvents, _ := regexp.Compile(`(BEGIN:VEVENT(.*\n)*?END:VEVENT\r\n)`)
-	allEvents := reEvents.FindAllString(iCalContent, len(iCalContent))
-	calInfo := reEvents.ReplaceAllString(iCalContent, "")
-	return allEvents, calInfo
-}
+func (p *Parser) fetchHTTP(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
 
-// parses the iCal Name
-func (p *Parser) parseICalName(iCalContent string) string {
-	re, _ := regexp.Compile(`X-WR-CALNAME:.*?\n`)
-	result := re.FindString(iCalContent)
-	return trimField(result, "X-WR-CALNAME:")
-}
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
 
-// parses the iCal description
-func (p *Parser) parseICalDesc(iCalContent string) string {
-	re, _ := regexp.Compile(`X-WR-CALDESC:.*?\n`)
-	result := re.FindString(iCalContent)
-	return trimField(result, "X-WR-CALDESC:")
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
-// parses the iCal version
-func (p *Parser) parseICalVersion(iCalContent string) float64 {
-	re, _ := regexp.Compile(`VERSION:.*?\n`)
-	result := re.FindString(iCalContent)
-	// parse the version result to float
-	ver, _ := strconv.ParseFloat(trimField(result, "VERSION:"), 64)
-	return ver
+// nextEventID hands out an id unique within this parser (two parsers can
+// hand out the same id), replacing the package-level mutex/counter globals so multiple parsers
+// can run in the same process without interfering with each other.
+func (p *Parser) nextEventID(importedID string) string {
+	p.idMu.Lock()
+	p.idCounter++
+	id := p.idCounter
+	p.idMu.Unlock()
+	return fmt.Sprintf("%s-%d", importedID, id)
 }
 
-// parses the iCal timezone
-func (p *Parser) parseICalTimezone(iCalContent string) time.Location {
-	re, _ := regexp.Compile(`X-WR-TIMEZONE:.*?\n`)
-	result := re.FindString(iCalContent)
-
-	// parse the timezone result to time.Location
-	timezone := trimField(result, "X-WR-TIMEZONE:")
-	fmt.Println(result)
-	loc, err := time.LoadLocation(timezone)
+// ======================== CALENDAR PARSING ===================
 
-	// if fails with the timezone => go Local
-	if err != nil {
-		fmt.Println(err)
-		loc, _ = time.LoadLocation("UTC")
+// builds a Calendar from raw iCal text. The content is unfolded and
+// tokenized once by parseComponentTree; every field below is a thin
+// lookup over the resulting property list instead of a regex scan of
+// the raw text.
+func (p *Parser) buildCalendar(content string, source Source) *Calendar {
+	cal := NewCalendar()
+	cal.SetSource(source)
+
+	root := parseComponentTree(content)
+	vcalendars := root.ChildrenOf("VCALENDAR")
+	if len(vcalendars) == 0 {
+		return cal
 	}
-	return *loc
-}
+	vcalendar := vcalendars[0]
 
-// ======================== EVENTS PARSING ===================
+	cal.SetName(propValue(vcalendar, "X-WR-CALNAME"))
+	cal.SetDesc(propValue(vcalendar, "X-WR-CALDESC"))
+	cal.SetVersion(parseFloatValue(propValue(vcalendar, "VERSION")))
+	cal.SetMethod(propValue(vcalendar, "METHOD"))
+	cal.SetTimezone(p.parseICalTimezone(vcalendar))
 
-// parses the iCal events Data
-func (p *Parser) parseEvents(cal *Calendar, eventsData []string) {
-	for _, eventData := range eventsData {
-		event := NewEvent()
-
-		start := p.parseEventStart(eventData)
-		end := p.parseEventEnd(eventData)
-		// whole day event when both times are 00:00:00
-		wholeDay := start.Hour() == 0 && end.Hour() == 0 && start.Minute() == 0 && end.Minute() == 0 && start.Second() == 0 && end.Second() == 0
-
-		event.SetStatus(p.parseEventStatus(eventData))
-		event.SetSummary(p.parseEventSummary(eventData))
-		event.SetDescription(p.parseEventDescription(eventData))
-		event.SetImportedID(p.parseEventId(eventData))
-		event.SetClass(p.parseEventClass(eventData))
-		event.SetSequence(p.parseEventSequence(eventData))
-		event.SetCreated(p.parseEventCreated(eventData))
-		event.SetLastModified(p.parseEventModified(eventData))
-		event.SetRRule(p.parseEventRRule(eventData))
-		event.SetStart(start)
-		event.SetEnd(end)
-		event.SetWholeDayEvent(wholeDay)
-		event.SetAttendees(p.parseEventAttendees(eventData))
-		event.SetCalendar(cal)
-		event.SetID(event.GenerateEventId())
+	// parse the VTIMEZONE blocks so DTSTART/DTEND TZID parameters can be
+	// resolved to a concrete UTC offset
+	tzs := parseVTimezoneComponents(vcalendar.ChildrenOf("VTIMEZONE"))
 
+	for _, eventData := range vcalendar.ChildrenOf("VEVENT") {
+		event := p.buildEvent(cal, eventData, tzs)
 		cal.SetEvent(*event)
-		p.bufferedChan <- event
-		// if event.GetRRule() != "" {
-		// 	fmt.Printf("%#v \n", event.GetRRule())
-		// }
-		// break
 	}
 
+	return cal
 }
 
-// parses the event summary
-func (p *Parser) parseEventSummary(eventData string) string {
-	re, _ := regexp.Compile(`SUMMARY:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "SUMMARY:")
-}
+// parses the iCal timezone (the non-standard X-WR-TIMEZONE property)
+func (p *Parser) parseICalTimezone(vcalendar *Component) time.Location {
+	timezone := propValue(vcalendar, "X-WR-TIMEZONE")
+	loc, err := time.LoadLocation(timezone)
 
-// parses the event status
-func (p *Parser) parseEventStatus(eventData string) string {
-	re, _ := regexp.Compile(`STATUS:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "STATUS:")
+	// if fails with the timezone => go UTC
+	if err != nil {
+		loc, _ = time.LoadLocation("UTC")
+	}
+	return *loc
 }
 
-// parses the event description
-func (p *Parser) parseEventDescription(eventData string) string {
-	re, _ := regexp.Compile(`DESCRIPTION:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "DESCRIPTION:")
+func parseFloatValue(value string) float64 {
+	v, _ := strconv.ParseFloat(value, 64)
+	return v
 }
 
-// parses the event id provided form google
-func (p *Parser) parseEventId(eventData string) string {
-	re, _ := regexp.Compile(`UID:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "UID:")
-}
+// ======================== EVENTS PARSING ===================
 
-// parses the event class
-func (p *Parser) parseEventClass(eventData string) string {
-	re, _ := regexp.Compile(`CLASS:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "CLASS:")
+// builds a single Event from its parsed VEVENT component
+func (p *Parser) buildEvent(cal *Calendar, eventData *Component, tzs map[string]*vtimezone) *Event {
+	event := NewEvent()
+
+	start, startFloating := p.parseEventStart(eventData, tzs)
+	end, endFloating := p.parseEventEnd(eventData, tzs)
+	// whole day event when both times are 00:00:00
+	wholeDay := start.Hour() == 0 && end.Hour() == 0 && start.Minute() == 0 && end.Minute() == 0 && start.Second() == 0 && end.Second() == 0
+
+	event.SetFloating(startFloating && endFloating)
+	event.SetStatus(propValue(eventData, "STATUS"))
+	event.SetSummary(propValue(eventData, "SUMMARY"))
+	event.SetDescription(propValue(eventData, "DESCRIPTION"))
+	event.SetImportedID(propValue(eventData, "UID"))
+	event.SetClass(propValue(eventData, "CLASS"))
+	event.SetSequence(p.parseEventSequence(eventData))
+	event.SetCreated(p.parseEventTimeProp(eventData, "CREATED"))
+	event.SetLastModified(p.parseEventTimeProp(eventData, "LAST-MODIFIED"))
+	event.SetRRule(propValue(eventData, "RRULE"))
+	event.SetExdate(p.parseEventDateListProp(eventData, "EXDATE", tzs))
+	event.SetRdate(p.parseEventDateListProp(eventData, "RDATE", tzs))
+	event.SetOrganizer(propValue(eventData, "ORGANIZER"))
+	event.SetStart(start)
+	event.SetEnd(end)
+	event.SetWholeDayEvent(wholeDay)
+	event.SetAttendees(p.parseEventAttendees(eventData))
+	event.SetCalendar(cal)
+	event.SetID(p.nextEventID(event.GetImportedID()))
+
+	return event
 }
 
 // parses the event sequence
-func (p *Parser) parseEventSequence(eventData string) int {
-	re, _ := regexp.Compile(`SEQUENCE:.*?\n`)
-	result := re.FindString(eventData)
-	sq, _ := strconv.Atoi(trimField(result, "SEQUENCE:"))
+func (p *Parser) parseEventSequence(eventData *Component) int {
+	sq, _ := strconv.Atoi(propValue(eventData, "SEQUENCE"))
 	return sq
 }
 
-// parses the event created time
-func (p *Parser) parseEventCreated(eventData string) time.Time {
-	re, _ := regexp.Compile(`CREATED:.*?\n`)
-	result := re.FindString(eventData)
-	created := trimField(result, "CREATED:")
-	t, _ := time.Parse(IcsFormat, created)
+// parses a CREATED/LAST-MODIFIED-shaped UTC date-time property
+func (p *Parser) parseEventTimeProp(eventData *Component, name string) time.Time {
+	t, _ := time.Parse(IcsFormat, propValue(eventData, name))
 	return t
 }
 
-// parses the event modified time
-func (p *Parser) parseEventModified(eventData string) time.Time {
-	re, _ := regexp.Compile(`LAST-MODIFIED:.*?\n`)
-	result := re.FindString(eventData)
-	modified := trimField(result, "LAST-MODIFIED:")
-	t, _ := time.Parse(IcsFormat, modified)
-	return t
+// parses the event start time, honouring TZID/VALUE=DATE parameters in
+// any order and a trailing Z. The second return value reports whether
+// the value is a floating local time (RFC 5545 §3.3.5).
+func (p *Parser) parseEventStart(eventData *Component, tzs map[string]*vtimezone) (time.Time, bool) {
+	return parseDateTimeProp(eventData, "DTSTART", tzs)
 }
 
-// parses the event start time
-func (p *Parser) parseEventStart(eventData string) time.Time {
-	reWholeDay, _ := regexp.Compile(`DTSTART;VALUE=DATE:.*?\n`)
-	re, _ := regexp.Compile(`DTSTART:.*?\n`)
-	resultWholeDay := reWholeDay.FindString(eventData)
-	var t time.Time
-
-	if resultWholeDay != "" {
-		// whole day event
-		modified := trimField(resultWholeDay, "DTSTART;VALUE=DATE:")
-		t, _ = time.Parse(IcsFormatWholeDay, modified)
-	} else {
-		// event that has start hour and minute
-		result := re.FindString(eventData)
-		modified := trimField(result, "DTSTART:")
-		t, _ = time.Parse(IcsFormat, modified)
-	}
-
-	return t
+// parses the event end time; see parseEventStart
+func (p *Parser) parseEventEnd(eventData *Component, tzs map[string]*vtimezone) (time.Time, bool) {
+	return parseDateTimeProp(eventData, "DTEND", tzs)
 }
 
-// parses the event end time
-func (p *Parser) parseEventEnd(eventData string) time.Time {
-	reWholeDay, _ := regexp.Compile(`DTEND;VALUE=DATE:.*?\n`)
-	re, _ := regexp.Compile(`DTEND:.*?\n`)
-	resultWholeDay := reWholeDay.FindString(eventData)
-	var t time.Time
-
-	if resultWholeDay != "" {
-		// whole day event
-		modified := trimField(resultWholeDay, "DTEND;VALUE=DATE:")
-		t, _ = time.Parse(IcsFormatWholeDay, modified)
-	} else {
-		// event that has end hour and minute
-		result := re.FindString(eventData)
-		modified := trimField(result, "DTEND:")
-		t, _ = time.Parse(IcsFormat, modified)
+// parses a DTSTART/DTEND-shaped property: VALUE=DATE whole-day values, a
+// TZID resolved against the calendar's VTIMEZONE blocks, and a trailing
+// Z forcing UTC. Returns the parsed time and whether it is a floating
+// local time.
+func parseDateTimeProp(eventData *Component, name string, tzs map[string]*vtimezone) (time.Time, bool) {
+	prop, ok := eventData.Get(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	return parseDateTimeValue(prop.Value, prop.Param("VALUE"), prop.Param("TZID"), tzs)
+}
+
+// parseDateTimeValue parses a single DATE/DATE-TIME value per RFC 5545
+// §3.3.4/§3.3.5, honouring a VALUE=DATE param, a TZID param resolved
+// against the calendar's VTIMEZONE blocks, and a trailing Z forcing UTC.
+// Returns the parsed time and whether it is a floating local time. Shared
+// by DTSTART/DTEND and by the EXDATE/RDATE list parser below, so the two
+// don't drift.
+func parseDateTimeValue(value, valueParam, tzid string, tzs map[string]*vtimezone) (time.Time, bool) {
+	if valueParam == "DATE" {
+		t, _ := time.Parse(IcsFormatWholeDay, value)
+		return t, false
 	}
-	return t
 
-}
+	if strings.HasSuffix(value, "Z") {
+		t, _ := time.Parse(IcsFormat, value)
+		return t, false
+	}
 
-// parses the event RRULE (the repeater)
-func (p *Parser) parseEventRRule(eventData string) string {
-	re, _ := regexp.Compile(`RRULE:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "RRULE:")
+	if tzid != "" {
+		loc := resolveLocation(tzid, tzs, value)
+		t, _ := time.ParseInLocation(IcsFormatLocal, value, loc)
+		return t, false
+	}
+
+	// floating: no TZID and no trailing Z
+	t, _ := time.ParseInLocation(IcsFormatLocal, value, time.Local)
+	return t, true
 }
 
-// ======================== ATTENDEE PARSING ===================
+// parses the event EXDATE/RDATE lines: each occurrence of the property
+// can carry a comma separated list of DATE or DATE-TIME values, and (like
+// DTSTART/DTEND) a VALUE=DATE or TZID parameter that applies to every
+// value in the list
+func (p *Parser) parseEventDateListProp(eventData *Component, name string, tzs map[string]*vtimezone) []time.Time {
+	var dates []time.Time
 
-// parses the event attendees
-func (p *Parser) parseEventAttendees(eventData string) []Attendee {
-	attendeesObj := []Attendee{}
-	re, _ := regexp.Compile(`ATTENDEE(:|;)(.*?\r\n)(\s.*?\r\n)*`)
-	attendees := re.FindAllString(eventData, len(eventData))
-
-	for _, attendeeData := range attendees {
-		if attendeeData == "" {
-			continue
-		}
-		attendee := p.parseAttendee(strings.Replace(attendeeData, "\r\n ", "", 1))
-		//  check for any fields set
-		if attendee.GetEmail() != "" || attendee.GetName() != "" || attendee.GetRole() != "" || attendee.GetStatus() != "" || attendee.GetType() != "" {
-			attendeesObj = append(attendeesObj, attendee)
+	for _, prop := range eventData.All(name) {
+		valueParam, tzid := prop.Param("VALUE"), prop.Param("TZID")
+		for _, raw := range strings.Split(prop.Value, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			t, _ := parseDateTimeValue(raw, valueParam, tzid, tzs)
+			if t.IsZero() {
+				continue
+			}
+			dates = append(dates, t)
 		}
 	}
-	return attendeesObj
-}
-
-//  parse attendee properties
-func (p *Parser) parseAttendee(attendeeData string) Attendee {
 
-	a := NewAttendee()
-	a.SetEmail(p.parseAttendeeStatus(attendeeData))
-	a.SetName(p.parseAttendeeName(attendeeData))
-	a.SetRole(p.parseAttendeeRole(attendeeData))
-	a.SetStatus(p.parseAttendeeStatus(attendeeData))
-	a.SetType(p.parseAttendeeType(attendeeData))
-
-	return *a
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
 }
 
-// parses the attendee email
-func (p *Parser) parseAttendeeMail(attendeeData string) string {
-	re, _ := regexp.Compile(`mailto:.*?\n`)
-	result := re.FindString(attendeeData)
-	return trimField(result, "mailto:")
-}
+// ======================== ATTENDEE PARSING ===================
 
-// parses the attendee status
-func (p *Parser) parseAttendeeStatus(attendeeData string) string {
-	re, _ := regexp.Compile(`PARTSTAT=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(PARTSTAT=|;)`)
-}
+// parses the event attendees
+func (p *Parser) parseEventAttendees(eventData *Component) []Attendee {
+	var attendees []Attendee
 
-// parses the attendee role
-func (p *Parser) parseAttendeeRole(attendeeData string) string {
-	re, _ := regexp.Compile(`ROLE=.*?;`)
-	result := re.FindString(attendeeData)
+	for _, prop := range eventData.All("ATTENDEE") {
+		a := NewAttendee()
+		a.SetEmail(strings.TrimPrefix(prop.Value, "mailto:"))
+		a.SetName(prop.Param("CN"))
+		a.SetRole(prop.Param("ROLE"))
+		a.SetStatus(prop.Param("PARTSTAT"))
+		a.SetType(prop.Param("CUTYPE"))
 
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(ROLE=|;)`)
-}
-
-// parses the attendee Name
-func (p *Parser) parseAttendeeName(attendeeData string) string {
-	re, _ := regexp.Compile(`CN=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
+		//  check for any fields set
+		if a.GetEmail() != "" || a.GetName() != "" || a.GetRole() != "" || a.GetStatus() != "" || a.GetType() != "" {
+			attendees = append(attendees, *a)
+		}
 	}
-	return trimField(result, `(CN=|;)`)
-}
 
-// parses the attendee type
-func (p *Parser) parseAttendeeType(attendeeData string) string {
-	re, _ := regexp.Compile(`CUTYPE=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(CUTYPE=|;)`)
+	return attendees
 }
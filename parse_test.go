@@ -0,0 +1,116 @@
+package ics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseReaderStreamsEvents(t *testing.T) {
+	p := NewParser(context.Background(), Options{})
+	defer p.Close()
+
+	raw := `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:1
+SUMMARY:Standup
+DTSTART:20240103T090000Z
+DTEND:20240103T093000Z
+END:VEVENT
+END:VCALENDAR
+`
+	events, errs := p.Parse(context.Background(), strings.NewReader(raw))
+
+	var got []*Event
+	for e := range events {
+		got = append(got, e)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].GetSummary() != "Standup" {
+		t.Fatalf("got %#v, want a single Standup event", got)
+	}
+}
+
+func TestParseMissingFileReportsError(t *testing.T) {
+	p := NewParser(context.Background(), Options{})
+	defer p.Close()
+
+	events, errs := p.Parse(context.Background(), "/no/such/file.ics")
+
+	for range events {
+	}
+	if err := <-errs; err == nil {
+		t.Fatal("expected an error for a missing file, got nil")
+	}
+}
+
+// caldavMultiFailureServer discovers two calendar collections and fails
+// FetchEvents for both, so ParseCalDAV must report two distinct errors.
+func caldavMultiFailureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	responses := map[string]string{
+		"/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:">
+  <D:response><D:href>/p/</D:href>
+    <D:propstat><D:prop><D:current-user-principal><D:href>/p/</D:href></D:current-user-principal></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+		"/p/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:response><D:href>/p/</D:href>
+    <D:propstat><D:prop><C:calendar-home-set><D:href>/cals/</D:href></C:calendar-home-set></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+		"/cals/": `<?xml version="1.0"?>
+<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:response><D:href>/cals/a/</D:href>
+    <D:propstat><D:prop><D:resourcetype><D:collection/><C:calendar/></D:resourcetype></D:prop></D:propstat>
+  </D:response>
+  <D:response><D:href>/cals/b/</D:href>
+    <D:propstat><D:prop><D:resourcetype><D:collection/><C:calendar/></D:resourcetype></D:prop></D:propstat>
+  </D:response>
+</D:multistatus>`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := responses[r.URL.Path]; ok {
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(body))
+			return
+		}
+		// both /cals/a/ and /cals/b/ REPORT requests land here and fail
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+}
+
+func TestParseCalDAVReportsEveryCollectionError(t *testing.T) {
+	srv := caldavMultiFailureServer(t)
+	defer srv.Close()
+
+	p := NewParser(context.Background(), Options{})
+	defer p.Close()
+
+	events, errs := p.ParseCalDAV(context.Background(), srv.URL, "", "", nil)
+
+	// drain events to completion first, exactly the consumption order
+	// that used to make the cap-1 errs buffer drop every error past the
+	// first one
+	for range events {
+	}
+
+	var got []error
+	for err := range errs {
+		got = append(got, err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d errors, want 2 (one per failing collection)", len(got))
+	}
+}
@@ -0,0 +1,557 @@
+package ics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byDayRule is a single BYDAY entry, e.g. "2MO" (the 2nd Monday) or "-1FR"
+// (the last Friday). Offset is 0 when no ordinal is given.
+type byDayRule struct {
+	Day    time.Weekday
+	Offset int
+}
+
+// Recurrence is the parsed form of an RRULE content line (RFC 5545 §3.3.10)
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []byDayRule
+	ByMonth    []int
+	ByMonthDay []int
+	BySetPos   []int
+	WKST       time.Weekday
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parses an RRULE value (everything after "RRULE:") into a Recurrence
+func parseRRule(rrule string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1, WKST: time.Monday}
+	if rrule == "" {
+		return nil, fmt.Errorf("ics: empty RRULE")
+	}
+
+	for _, part := range strings.Split(rrule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "FREQ":
+			r.Freq = value
+		case "INTERVAL":
+			if n, err := strconv.Atoi(value); err == nil {
+				r.Interval = n
+			}
+		case "COUNT":
+			if n, err := strconv.Atoi(value); err == nil {
+				r.Count = n
+			}
+		case "UNTIL":
+			if t, err := time.Parse(IcsFormat, value); err == nil {
+				r.Until = t
+			} else if t, err := time.Parse(IcsFormatWholeDay, value); err == nil {
+				r.Until = t
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				if rule, ok := parseByDay(day); ok {
+					r.ByDay = append(r.ByDay, rule)
+				}
+			}
+		case "BYMONTH":
+			r.ByMonth = parseIntList(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay = parseIntList(value)
+		case "BYSETPOS":
+			r.BySetPos = parseIntList(value)
+		case "WKST":
+			if wd, ok := weekdayNames[strings.ToUpper(value)]; ok {
+				r.WKST = wd
+			}
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("ics: RRULE missing FREQ: %q", rrule)
+	}
+	return r, nil
+}
+
+func parseByDay(value string) (byDayRule, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return byDayRule{}, false
+	}
+	dayCode := value[len(value)-2:]
+	day, ok := weekdayNames[dayCode]
+	if !ok {
+		return byDayRule{}, false
+	}
+	offset := 0
+	if ord := value[:len(value)-2]; ord != "" {
+		if n, err := strconv.Atoi(ord); err == nil {
+			offset = n
+		}
+	}
+	return byDayRule{Day: day, Offset: offset}, true
+}
+
+func parseIntList(value string) []int {
+	var out []int
+	for _, v := range strings.Split(value, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// recurrenceGenerator lazily yields the occurrences of a recurring event,
+// caching candidates so repeated Occurrences() calls on overlapping
+// windows don't re-walk the series from the beginning.
+type recurrenceGenerator struct {
+	rule       *Recurrence
+	exdate     map[time.Time]bool
+	rdate      []time.Time
+	dtstart    time.Time
+	cursor     time.Time
+	produced   int
+	done       bool
+	cache      []time.Time
+	emptySteps int
+}
+
+// maxEmptySteps bounds how many consecutive cursor steps may produce zero
+// candidates before the generator gives up. An RRULE with no COUNT/UNTIL
+// whose BY* filters can never match anything (e.g. a bug in this package,
+// or a contrived rule that simply admits no instances) would otherwise
+// spin fill() forever.
+const maxEmptySteps = 10000
+
+func newRecurrenceGenerator(e *Event) (*recurrenceGenerator, error) {
+	rule, err := parseRRule(e.GetRRule())
+	if err != nil {
+		return nil, err
+	}
+
+	exdate := make(map[time.Time]bool, len(e.GetExdate()))
+	for _, t := range e.GetExdate() {
+		// keyed by the UTC instant: EXDATE/DTSTART can carry equal instants
+		// represented against different *time.Location pointers (e.g. a
+		// TZID-resolved zone vs time.Local), which compare unequal as raw
+		// map keys even though they denote the same moment
+		exdate[t.UTC()] = true
+	}
+
+	return &recurrenceGenerator{
+		rule:    rule,
+		exdate:  exdate,
+		rdate:   e.GetRdate(),
+		dtstart: e.GetStart(),
+		cursor:  e.GetStart(),
+	}, nil
+}
+
+// advances the cursor until it has produced at least n cached instances
+// or the series has terminated (COUNT/UNTIL reached)
+func (g *recurrenceGenerator) fill(n int) {
+	for len(g.cache) < n && !g.done {
+		candidates := g.expandStep()
+		g.cursor = stepCursor(g.cursor, g.rule.Freq, g.rule.Interval)
+
+		if len(candidates) == 0 {
+			g.emptySteps++
+			if g.emptySteps >= maxEmptySteps {
+				g.done = true
+			}
+			continue
+		}
+		g.emptySteps = 0
+
+		for _, c := range candidates {
+			if c.Before(g.dtstart) {
+				continue
+			}
+			if g.rule.Count > 0 && g.produced >= g.rule.Count {
+				g.done = true
+				break
+			}
+			if !g.rule.Until.IsZero() && c.After(g.rule.Until) {
+				g.done = true
+				break
+			}
+			if g.exdate[c.UTC()] {
+				continue
+			}
+			g.cache = append(g.cache, c)
+			g.produced++
+		}
+
+		if g.rule.Freq == "" {
+			g.done = true
+		}
+	}
+}
+
+// expandStep generates the BYDAY/BYMONTH/BYMONTHDAY/BYSETPOS candidates
+// for the current cursor position, applying the BY* rules in the order
+// mandated by RFC 5545 §3.3.10
+func (g *recurrenceGenerator) expandStep() []time.Time {
+	candidates := []time.Time{g.cursor}
+
+	if len(g.rule.ByMonth) > 0 {
+		candidates = filterByMonth(candidates, g.rule.ByMonth)
+	}
+	if len(g.rule.ByMonthDay) > 0 {
+		candidates = expandByMonthDay(candidates, g.rule.ByMonthDay)
+	}
+	if len(g.rule.ByDay) > 0 {
+		candidates = expandByDay(candidates, g.rule.ByDay, g.rule.Freq, g.rule.WKST)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	if len(g.rule.BySetPos) > 0 {
+		candidates = filterBySetPos(candidates, g.rule.BySetPos)
+	}
+
+	return candidates
+}
+
+func filterByMonth(in []time.Time, months []int) []time.Time {
+	var out []time.Time
+	for _, t := range in {
+		for _, m := range months {
+			if int(t.Month()) == m {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func expandByMonthDay(in []time.Time, days []int) []time.Time {
+	var out []time.Time
+	for _, t := range in {
+		for _, d := range days {
+			day := d
+			if day < 0 {
+				lastOfMonth := time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 0, -1)
+				day = lastOfMonth.Day() + day + 1
+			}
+			out = append(out, time.Date(t.Year(), t.Month(), day, t.Hour(), t.Minute(), t.Second(), 0, t.Location()))
+		}
+	}
+	return out
+}
+
+func expandByDay(in []time.Time, rules []byDayRule, freq string, wkst time.Weekday) []time.Time {
+	var out []time.Time
+	for _, t := range in {
+		switch freq {
+		case "MONTHLY", "YEARLY":
+			out = append(out, monthlyByDay(t, rules)...)
+		case "WEEKLY":
+			out = append(out, weeklyByDay(t, rules, wkst)...)
+		default:
+			for _, rule := range rules {
+				if t.Weekday() == rule.Day {
+					out = append(out, t)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// weeklyByDay expands a WEEKLY cursor instant to every day in its
+// WKST-aligned week that matches one of rules. A WEEKLY RRULE's BYDAY set
+// is not required to include the cursor's own weekday (e.g. a Wednesday
+// DTSTART with BYDAY=MO is a common, legal shape: the first instance is
+// the Monday after DTSTART), so the cursor must expand across the
+// whole week rather than only ever testing its own weekday.
+func weeklyByDay(t time.Time, rules []byDayRule, wkst time.Weekday) []time.Time {
+	offset := (int(t.Weekday()) - int(wkst) + 7) % 7
+	weekStart := t.AddDate(0, 0, -offset)
+
+	var out []time.Time
+	for d := 0; d < 7; d++ {
+		day := weekStart.AddDate(0, 0, d)
+		for _, rule := range rules {
+			if day.Weekday() == rule.Day {
+				out = append(out, day)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func monthlyByDay(t time.Time, rules []byDayRule) []time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	next := first.AddDate(0, 1, 0)
+
+	var matches []time.Time
+	for d := first; d.Before(next); d = d.AddDate(0, 0, 1) {
+		for _, rule := range rules {
+			if d.Weekday() != rule.Day {
+				continue
+			}
+			if rule.Offset == 0 {
+				matches = append(matches, d)
+			}
+		}
+	}
+
+	var out []time.Time
+	for _, rule := range rules {
+		if rule.Offset == 0 {
+			continue
+		}
+		if occ := nthWeekdayOfMonth(first, rule.Day, rule.Offset); !occ.IsZero() {
+			out = append(out, occ)
+		}
+	}
+	return append(out, matches...)
+}
+
+func nthWeekdayOfMonth(monthStart time.Time, day time.Weekday, n int) time.Time {
+	next := monthStart.AddDate(0, 1, 0)
+	var days []time.Time
+	for d := monthStart; d.Before(next); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == day {
+			days = append(days, d)
+		}
+	}
+	if len(days) == 0 {
+		return time.Time{}
+	}
+	if n > 0 {
+		if n-1 < len(days) {
+			return days[n-1]
+		}
+		return time.Time{}
+	}
+	idx := len(days) + n
+	if idx >= 0 && idx < len(days) {
+		return days[idx]
+	}
+	return time.Time{}
+}
+
+func filterBySetPos(in []time.Time, positions []int) []time.Time {
+	var out []time.Time
+	for _, pos := range positions {
+		if pos > 0 && pos-1 < len(in) {
+			out = append(out, in[pos-1])
+		} else if pos < 0 && len(in)+pos >= 0 {
+			out = append(out, in[len(in)+pos])
+		}
+	}
+	return out
+}
+
+func stepCursor(cursor time.Time, freq string, interval int) time.Time {
+	switch freq {
+	case "SECONDLY":
+		return cursor.Add(time.Duration(interval) * time.Second)
+	case "MINUTELY":
+		return cursor.Add(time.Duration(interval) * time.Minute)
+	case "HOURLY":
+		return cursor.Add(time.Duration(interval) * time.Hour)
+	case "DAILY":
+		return cursor.AddDate(0, 0, interval)
+	case "WEEKLY":
+		return cursor.AddDate(0, 0, 7*interval)
+	case "MONTHLY":
+		return cursor.AddDate(0, interval, 0)
+	case "YEARLY":
+		return cursor.AddDate(interval, 0, 0)
+	default:
+		return cursor
+	}
+}
+
+// instanceAt clones the parent event into a concrete occurrence starting
+// at t, shifting Start/End by the same delta and stamping a RecurrenceID
+func instanceAt(parent *Event, t time.Time) *Event {
+	delta := t.Sub(parent.GetStart())
+	instance := parent.clone()
+	instance.SetStart(t)
+	instance.SetEnd(parent.GetEnd().Add(delta))
+	instance.SetRecurrenceID(t.Format(IcsFormat))
+	return instance
+}
+
+// Occurrences returns the concrete instances of a recurring event whose
+// start falls within [from, to). Non-recurring events return a single
+// instance if their own Start falls in the window, or nil otherwise.
+func (e *Event) Occurrences(from, to time.Time) []*Event {
+	if e.GetRRule() == "" {
+		if len(e.GetRdate()) == 0 {
+			if !e.GetStart().Before(from) && e.GetStart().Before(to) {
+				return []*Event{e}
+			}
+			return nil
+		}
+	}
+
+	it := e.Iterator(from)
+	var out []*Event
+	for {
+		instance, ok := it.Next()
+		if !ok || !instance.GetStart().Before(to) {
+			break
+		}
+		out = append(out, instance)
+	}
+	return out
+}
+
+// OccurrenceIterator streams the instances of a recurring event in order,
+// starting at the first occurrence not before `from`
+type OccurrenceIterator struct {
+	event  *Event
+	gen    *recurrenceGenerator
+	rdate  []time.Time
+	cursor int
+	from   time.Time
+	// genPos indexes the shared gen.cache, which is never consumed, so
+	// later iterators over the same event still see every instance
+	genPos int
+}
+
+// Iterator returns a streaming iterator over the occurrences of the event,
+// starting at the first instance not before `from`
+func (e *Event) Iterator(from time.Time) *OccurrenceIterator {
+	it := &OccurrenceIterator{event: e, from: from}
+
+	if e.GetRRule() != "" {
+		if e.occurrenceGen == nil {
+			gen, err := newRecurrenceGenerator(e)
+			if err == nil {
+				e.occurrenceGen = gen
+			}
+		}
+		it.gen = e.occurrenceGen
+		if it.gen != nil {
+			it.genPos = sort.Search(len(it.gen.cache), func(i int) bool { return !it.gen.cache[i].Before(from) })
+		}
+	}
+
+	rdate := e.GetRdate()
+	if it.gen == nil {
+		// without an RRULE there's no recurrenceGenerator to produce
+		// DTSTART, so fold it into the RDATE set here to keep it an
+		// instance of the recurrence set alongside the RDATEs.
+		rdate = append(append([]time.Time{}, rdate...), e.GetStart())
+	}
+
+	exdate := make(map[time.Time]bool, len(e.GetExdate()))
+	for _, t := range e.GetExdate() {
+		exdate[t.UTC()] = true
+	}
+
+	it.rdate = mergeRdate(rdate, exdate)
+	return it
+}
+
+// mergeRdate sorts rdate into occurrence order, dropping any instant that
+// also appears in exdate (RFC 5545 §3.8.5.2: EXDATE excludes from the
+// whole recurrence set, not just the RRULE-generated part of it) or that
+// duplicates an instant already kept.
+func mergeRdate(rdate []time.Time, exdate map[time.Time]bool) []time.Time {
+	sorted := make([]time.Time, len(rdate))
+	copy(sorted, rdate)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	out := make([]time.Time, 0, len(sorted))
+	seen := make(map[time.Time]bool, len(sorted))
+	for _, t := range sorted {
+		key := t.UTC()
+		if exdate[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// Next returns the next occurrence at or after the iterator's `from` time.
+// ok is false once the recurrence series (and any RDATE entries) are
+// exhausted.
+func (it *OccurrenceIterator) Next() (*Event, bool) {
+	if it.gen == nil && it.cursor >= len(it.rdate) {
+		return nil, false
+	}
+
+	for {
+		var genCandidate time.Time
+		hasGen := false
+		if it.gen != nil {
+			it.gen.fill(it.genPos + 1)
+			if it.genPos < len(it.gen.cache) {
+				genCandidate = it.gen.cache[it.genPos]
+				hasGen = true
+			}
+		}
+
+		var rdateCandidate time.Time
+		hasRdate := it.cursor < len(it.rdate)
+		if hasRdate {
+			rdateCandidate = it.rdate[it.cursor]
+		}
+
+		var next time.Time
+		switch {
+		case hasGen && hasRdate:
+			switch {
+			case genCandidate.Before(rdateCandidate):
+				next = genCandidate
+				it.genPos++
+			case rdateCandidate.Before(genCandidate):
+				next = rdateCandidate
+				it.cursor++
+			default:
+				// the same instant from both sources: emit it once and
+				// advance past it on both sides, or it would be re-emitted
+				// as a duplicate on the next call
+				next = genCandidate
+				it.genPos++
+				it.cursor++
+			}
+		case hasGen:
+			next = genCandidate
+			it.genPos++
+		case hasRdate:
+			next = rdateCandidate
+			it.cursor++
+		default:
+			return nil, false
+		}
+
+		if next.Before(it.from) {
+			continue
+		}
+		return instanceAt(it.event, next), true
+	}
+}
@@ -0,0 +1,132 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+func mustEvent(t *testing.T, start time.Time, rrule string) *Event {
+	t.Helper()
+	e := NewEvent()
+	e.SetStart(start)
+	e.SetEnd(start.Add(time.Hour))
+	e.SetRRule(rrule)
+	return e
+}
+
+// occurrencesWithTimeout guards against the recurrenceGenerator spinning forever
+// on a legal-but-tricky RRULE shape (the bug this test was written for).
+func occurrencesWithTimeout(t *testing.T, e *Event, from, to time.Time) []*Event {
+	t.Helper()
+	done := make(chan []*Event, 1)
+	go func() { done <- e.Occurrences(from, to) }()
+
+	select {
+	case out := <-done:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("Occurrences did not return within 5s, recurrenceGenerator likely hung")
+		return nil
+	}
+}
+
+func TestWeeklyByDayDTStartNotInSet(t *testing.T) {
+	// DTSTART on a Wednesday, but BYDAY only names Monday: the first
+	// instance is the Monday after DTSTART, not DTSTART itself (the Monday
+	// of DTSTART's own week precedes DTSTART and is skipped).
+	start := time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC) // a Wednesday
+	e := mustEvent(t, start, "FREQ=WEEKLY;BYDAY=MO;COUNT=3")
+
+	out := occurrencesWithTimeout(t, e, start, start.AddDate(1, 0, 0))
+	if len(out) != 3 {
+		t.Fatalf("got %d occurrences, want 3", len(out))
+	}
+
+	want := []time.Time{
+		time.Date(2024, time.January, 8, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 22, 9, 0, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		if !out[i].GetStart().Equal(w) {
+			t.Errorf("occurrence %d = %v, want %v", i, out[i].GetStart(), w)
+		}
+		if out[i].GetStart().Weekday() != time.Monday {
+			t.Errorf("occurrence %d falls on %s, want Monday", i, out[i].GetStart().Weekday())
+		}
+	}
+}
+
+func TestRuleThatNeverMatchesTerminates(t *testing.T) {
+	// BYMONTH=13 can never equal a real month: every step's filterByMonth
+	// empties the candidate set. With no COUNT/UNTIL, fill() must still
+	// terminate via the empty-step safety cap rather than hang forever.
+	start := time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC)
+	e := mustEvent(t, start, "FREQ=WEEKLY;BYMONTH=13")
+
+	out := occurrencesWithTimeout(t, e, start, start.AddDate(0, 1, 0))
+	if len(out) != 0 {
+		t.Fatalf("got %d occurrences, want 0", len(out))
+	}
+}
+
+func TestOccurrencesSkipsExdateAcrossLocations(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("Europe/Berlin zone data not available")
+	}
+
+	start := time.Date(2024, time.January, 3, 9, 0, 0, 0, berlin)
+	e := mustEvent(t, start, "FREQ=DAILY")
+
+	// the same instant, but expressed in UTC rather than Europe/Berlin -
+	// exclusion must match on the instant, not the Location pointer
+	excluded := time.Date(2024, time.January, 4, 8, 0, 0, 0, time.UTC)
+	e.SetExdate([]time.Time{excluded})
+
+	out := occurrencesWithTimeout(t, e, start, start.AddDate(0, 0, 5))
+	if len(out) != 4 {
+		t.Fatalf("got %d occurrences, want 4 (one excluded)", len(out))
+	}
+	for _, o := range out {
+		if o.GetStart().UTC().Equal(excluded.UTC()) {
+			t.Fatalf("excluded instant %v was not removed", excluded)
+		}
+	}
+}
+
+func TestOccurrencesNonRecurring(t *testing.T) {
+	start := time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC)
+	e := mustEvent(t, start, "")
+
+	out := e.Occurrences(start, start.Add(time.Hour))
+	if len(out) != 1 {
+		t.Fatalf("got %d occurrences, want 1", len(out))
+	}
+
+	out = e.Occurrences(start.Add(time.Hour), start.Add(2*time.Hour))
+	if len(out) != 0 {
+		t.Fatalf("got %d occurrences outside window, want 0", len(out))
+	}
+}
+
+func TestMonthlyByDaySetPos(t *testing.T) {
+	// last Friday of each month
+	start := time.Date(2024, time.January, 26, 9, 0, 0, 0, time.UTC)
+	e := mustEvent(t, start, "FREQ=MONTHLY;BYDAY=FR;BYSETPOS=-1;COUNT=3")
+
+	out := occurrencesWithTimeout(t, e, start, start.AddDate(1, 0, 0))
+	if len(out) != 3 {
+		t.Fatalf("got %d occurrences, want 3", len(out))
+	}
+	want := []time.Time{
+		time.Date(2024, time.January, 26, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.February, 23, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 29, 9, 0, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		if !out[i].GetStart().Equal(w) {
+			t.Errorf("occurrence %d = %v, want %v", i, out[i].GetStart(), w)
+		}
+	}
+}
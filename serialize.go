@@ -0,0 +1,206 @@
+package ics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const icsLineFoldLimit = 75
+
+// Serialize renders the calendar as RFC 5545 text: VERSION, PRODID, the
+// calendar's own properties, and every event as a VEVENT component, with
+// content lines folded at 75 octets per §3.1.
+func (c *Calendar) Serialize() ([]byte, error) {
+	var b strings.Builder
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, fmt.Sprintf("VERSION:%s", formatVersion(c.GetVersion())))
+	writeLine(&b, "PRODID:-//ics-golang//ics-golang//EN")
+	if method := c.GetMethod(); method != "" {
+		writeLine(&b, "METHOD:"+method)
+	}
+	if name := c.GetName(); name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+	}
+	if desc := c.GetDesc(); desc != "" {
+		writeLine(&b, "X-WR-CALDESC:"+escapeText(desc))
+	}
+
+	for _, event := range c.GetEvents() {
+		writeEvent(&b, event)
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// Serialize renders a single event as a standalone VCALENDAR containing
+// one VEVENT, suitable for attaching as a text/calendar MIME part.
+func (e *Event) Serialize() ([]byte, error) {
+	var b strings.Builder
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//ics-golang//ics-golang//EN")
+	writeEvent(&b, e)
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// CreateReply consumes a METHOD:REQUEST invitation event and builds a
+// METHOD:REPLY calendar carrying a single ATTENDEE line with the given
+// PARTSTAT, preserving UID/SEQUENCE/DTSTART/DTEND/ORGANIZER and stamping
+// a fresh DTSTAMP on serialization - the shape a mail client needs to
+// answer a meeting invite delivered as a text/calendar;method=REQUEST
+// MIME part.
+func (e *Event) CreateReply(from, partstat string) (*Calendar, error) {
+	switch partstat {
+	case "ACCEPTED", "TENTATIVE", "DECLINED":
+	default:
+		return nil, fmt.Errorf("ics: invalid PARTSTAT %q for reply", partstat)
+	}
+
+	reply := e.clone()
+	attendee := NewAttendee()
+	// from is commonly GetOrganizer(), which is stored with its mailto:
+	// prefix intact; serializeAttendee always adds its own, so strip one
+	// here the same way parseEventAttendees does for parsed attendees
+	attendee.SetEmail(strings.TrimPrefix(from, "mailto:"))
+	attendee.SetStatus(partstat)
+	reply.SetAttendees([]Attendee{*attendee})
+
+	cal := NewCalendar()
+	cal.SetVersion(2.0)
+	cal.SetMethod("REPLY")
+	cal.SetEvent(*reply)
+	return cal, nil
+}
+
+func writeEvent(b *strings.Builder, e *Event) {
+	writeLine(b, "BEGIN:VEVENT")
+	writeLine(b, "UID:"+escapeText(e.GetImportedID()))
+	writeLine(b, "DTSTAMP:"+time.Now().UTC().Format(IcsFormat))
+	writeLine(b, formatDateTimeProperty("DTSTART", e.GetStart(), e.GetWholeDayEvent()))
+	writeLine(b, formatDateTimeProperty("DTEND", e.GetEnd(), e.GetWholeDayEvent()))
+	if e.GetOrganizer() != "" {
+		writeLine(b, "ORGANIZER:"+e.GetOrganizer())
+	}
+	if e.GetSummary() != "" {
+		writeLine(b, "SUMMARY:"+escapeText(e.GetSummary()))
+	}
+	if e.GetDescription() != "" {
+		writeLine(b, "DESCRIPTION:"+escapeText(e.GetDescription()))
+	}
+	if e.GetStatus() != "" {
+		writeLine(b, "STATUS:"+e.GetStatus())
+	}
+	if e.GetClass() != "" {
+		writeLine(b, "CLASS:"+e.GetClass())
+	}
+	writeLine(b, fmt.Sprintf("SEQUENCE:%d", e.GetSequence()))
+	if e.GetRRule() != "" {
+		writeLine(b, "RRULE:"+e.GetRRule())
+	}
+	for _, a := range e.GetAttendees() {
+		writeLine(b, serializeAttendee(a))
+	}
+	writeLine(b, "END:VEVENT")
+}
+
+func serializeAttendee(a Attendee) string {
+	var params []string
+	if a.GetRole() != "" {
+		params = append(params, "ROLE="+a.GetRole())
+	}
+	if a.GetStatus() != "" {
+		params = append(params, "PARTSTAT="+a.GetStatus())
+	}
+	if a.GetType() != "" {
+		params = append(params, "CUTYPE="+a.GetType())
+	}
+	if a.GetName() != "" {
+		params = append(params, "CN="+quoteParamValue(a.GetName()))
+	}
+
+	line := "ATTENDEE"
+	if len(params) > 0 {
+		line += ";" + strings.Join(params, ";")
+	}
+	return line + ":mailto:" + a.GetEmail()
+}
+
+// quoteParamValue DQUOTE-wraps a parameter value if it contains any of
+// the characters that would otherwise be ambiguous in a content line
+func quoteParamValue(value string) string {
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+func formatVersion(version float64) string {
+	if version == 0 {
+		version = 2.0
+	}
+	return fmt.Sprintf("%.1f", version)
+}
+
+func formatDateTimeProperty(name string, t time.Time, wholeDay bool) string {
+	if wholeDay {
+		return fmt.Sprintf("%s;VALUE=DATE:%s", name, t.Format(IcsFormatWholeDay))
+	}
+	return fmt.Sprintf("%s:%s", name, t.UTC().Format(IcsFormat))
+}
+
+// escapeText escapes the characters RFC 5545 §3.3.11 requires backslash
+// escaping in a TEXT value
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// writeLine appends a content line, folded to icsLineFoldLimit octets
+// per RFC 5545 §3.1, terminated with CRLF
+func writeLine(b *strings.Builder, line string) {
+	b.WriteString(foldLine(line))
+	b.WriteString("\r\n")
+}
+
+// foldLine wraps line into RFC 5545 §3.1 content lines: no line may
+// exceed 75 *octets* (UTF-8 bytes, not runes), and each continuation
+// starts with a single leading space. A fold point is never allowed to
+// land inside a multi-byte UTF-8 sequence, so a single non-ASCII
+// character (CJK, Cyrillic, emoji, ...) can push a chunk up to three
+// bytes under the limit.
+func foldLine(line string) string {
+	if len(line) <= icsLineFoldLimit {
+		return line
+	}
+
+	var folded strings.Builder
+	limit := icsLineFoldLimit
+	for len(line) > 0 {
+		if folded.Len() > 0 {
+			folded.WriteString("\r\n ")
+			limit = icsLineFoldLimit - 1
+		}
+		n := limit
+		if n >= len(line) {
+			n = len(line)
+		} else {
+			for n > 0 && !utf8.RuneStart(line[n]) {
+				n--
+			}
+		}
+		folded.WriteString(line[:n])
+		line = line[n:]
+	}
+	return folded.String()
+}
@@ -0,0 +1,88 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func mustReplyEvent(t *testing.T) *Event {
+	t.Helper()
+	e := NewEvent()
+	e.SetImportedID("abc123")
+	e.SetStart(time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC))
+	e.SetEnd(time.Date(2024, time.January, 3, 10, 0, 0, 0, time.UTC))
+	e.SetOrganizer("mailto:organizer@example.com")
+	e.SetSequence(2)
+	return e
+}
+
+func TestCreateReplyStripsExistingMailtoPrefix(t *testing.T) {
+	e := mustReplyEvent(t)
+
+	cal, err := e.CreateReply(e.GetOrganizer(), "ACCEPTED")
+	if err != nil {
+		t.Fatalf("CreateReply: %v", err)
+	}
+
+	out, err := cal.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+
+	if strings.Contains(string(out), "mailto:mailto:") {
+		t.Fatalf("ATTENDEE line has a doubled mailto: prefix:\n%s", out)
+	}
+	if !strings.Contains(string(out), "ATTENDEE;PARTSTAT=ACCEPTED:mailto:organizer@example.com") {
+		t.Fatalf("expected a single mailto:-prefixed ATTENDEE line, got:\n%s", out)
+	}
+}
+
+func TestCreateReplyAcceptsBareEmailToo(t *testing.T) {
+	e := mustReplyEvent(t)
+
+	cal, err := e.CreateReply("someone-else@example.com", "DECLINED")
+	if err != nil {
+		t.Fatalf("CreateReply: %v", err)
+	}
+
+	out, _ := cal.Serialize()
+	if !strings.Contains(string(out), "ATTENDEE;PARTSTAT=DECLINED:mailto:someone-else@example.com") {
+		t.Fatalf("expected the bare email to gain exactly one mailto: prefix, got:\n%s", out)
+	}
+}
+
+func TestCreateReplyRejectsUnknownPartstat(t *testing.T) {
+	e := mustReplyEvent(t)
+
+	if _, err := e.CreateReply(e.GetOrganizer(), "MAYBE"); err == nil {
+		t.Fatal("expected an error for an invalid PARTSTAT, got nil")
+	}
+}
+
+func TestFoldLineRespectsOctetLimitNotRuneCount(t *testing.T) {
+	// each "é" is 2 bytes in UTF-8; 40 of them is 80 octets, over the
+	// 75-octet limit, but only 40 runes
+	line := strings.Repeat("é", 40)
+	folded := foldLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n ") {
+		if len(part) > icsLineFoldLimit {
+			t.Fatalf("fold chunk %q is %d octets, over the %d limit", part, len(part), icsLineFoldLimit)
+		}
+	}
+	for _, part := range strings.Split(folded, "\r\n ") {
+		if !utf8.ValidString(part) {
+			t.Fatalf("fold point landed inside a multi-byte rune: %q", folded)
+		}
+	}
+}
+
+func TestEscapeTextEscapesSpecialCharacters(t *testing.T) {
+	got := escapeText("a, b; c\\d\ne")
+	want := `a\, b\; c\\d\ne`
+	if got != want {
+		t.Errorf("escapeText = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package ics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tzTransitionRule is a single STANDARD or DAYLIGHT sub-component of a
+// VTIMEZONE: the offset either side of the transition, its name, and the
+// RRULE (or one-off DTSTART) describing when it recurs
+type tzTransitionRule struct {
+	start      time.Time
+	offsetFrom string
+	offsetTo   string
+	name       string
+	rrule      *Recurrence
+}
+
+// vtimezone is the parsed form of a BEGIN:VTIMEZONE...END:VTIMEZONE block
+type vtimezone struct {
+	tzid     string
+	standard *tzTransitionRule
+	daylight *tzTransitionRule
+}
+
+// parseVTimezoneComponents turns the VTIMEZONE child components of a
+// VCALENDAR into vtimezone values keyed by TZID
+func parseVTimezoneComponents(components []*Component) map[string]*vtimezone {
+	tzs := map[string]*vtimezone{}
+
+	for _, c := range components {
+		tzid := propValue(c, "TZID")
+		if tzid == "" {
+			continue
+		}
+
+		tzs[tzid] = &vtimezone{
+			tzid:     tzid,
+			standard: parseTzTransitionRule(firstChild(c, "STANDARD")),
+			daylight: parseTzTransitionRule(firstChild(c, "DAYLIGHT")),
+		}
+	}
+
+	return tzs
+}
+
+func firstChild(c *Component, name string) *Component {
+	if children := c.ChildrenOf(name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+func parseTzTransitionRule(c *Component) *tzTransitionRule {
+	if c == nil {
+		return nil
+	}
+
+	start, _ := time.Parse(IcsFormatLocal, propValue(c, "DTSTART"))
+
+	rule := &tzTransitionRule{
+		start:      start,
+		offsetFrom: propValue(c, "TZOFFSETFROM"),
+		offsetTo:   propValue(c, "TZOFFSETTO"),
+		name:       propValue(c, "TZNAME"),
+	}
+
+	if rruleStr := propValue(c, "RRULE"); rruleStr != "" {
+		if r, err := parseRRule(rruleStr); err == nil {
+			rule.rrule = r
+		}
+	}
+
+	return rule
+}
+
+// transitionIn returns the instant (in naive local time) at which the
+// rule takes effect during the given year, evaluating the rule's RRULE
+// if it has one or falling back to its one-off DTSTART otherwise
+func (r *tzTransitionRule) transitionIn(year int) time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	if r.rrule == nil {
+		if r.start.Year() == year {
+			return r.start
+		}
+		return time.Time{}
+	}
+
+	base := time.Date(year, r.start.Month(), r.start.Day(), r.start.Hour(), r.start.Minute(), r.start.Second(), 0, time.UTC)
+	if len(r.rrule.ByMonth) > 0 {
+		base = time.Date(year, time.Month(r.rrule.ByMonth[0]), 1, r.start.Hour(), r.start.Minute(), r.start.Second(), 0, time.UTC)
+	}
+	if len(r.rrule.ByDay) > 0 {
+		if days := monthlyByDay(base, r.rrule.ByDay); len(days) > 0 {
+			return days[0]
+		}
+	}
+	return base
+}
+
+// offsetFor picks the STANDARD or DAYLIGHT offset that applies to the
+// given naive local instant, by comparing it against the year's
+// STANDARD/DAYLIGHT transition instants
+func (tz *vtimezone) offsetFor(instant time.Time) (name string, offset string) {
+	if tz.daylight == nil || tz.standard == nil {
+		if tz.standard != nil {
+			return tz.standard.name, tz.standard.offsetTo
+		}
+		if tz.daylight != nil {
+			return tz.daylight.name, tz.daylight.offsetTo
+		}
+		// a VTIMEZONE with neither a STANDARD nor a DAYLIGHT sub-component
+		// carries no offset at all; the caller falls back to an IANA zone
+		// lookup (or UTC) when this comes back empty
+		return "", ""
+	}
+
+	year := instant.Year()
+	dstStart := tz.daylight.transitionIn(year)
+	stdStart := tz.standard.transitionIn(year)
+
+	if dstStart.IsZero() || stdStart.IsZero() {
+		return tz.standard.name, tz.standard.offsetTo
+	}
+
+	if dstStart.Before(stdStart) {
+		if !instant.Before(dstStart) && instant.Before(stdStart) {
+			return tz.daylight.name, tz.daylight.offsetTo
+		}
+		return tz.standard.name, tz.standard.offsetTo
+	}
+
+	if !instant.Before(stdStart) && instant.Before(dstStart) {
+		return tz.standard.name, tz.standard.offsetTo
+	}
+	return tz.daylight.name, tz.daylight.offsetTo
+}
+
+// resolveLocation builds the *time.Location that applies to a naive local
+// value carrying the given TZID: a fixed-offset zone derived from the
+// VTIMEZONE's STANDARD/DAYLIGHT rules when one was parsed out of the
+// calendar, or a IANA zone database lookup as a fallback.
+func resolveLocation(tzid string, tzs map[string]*vtimezone, naiveValue string) *time.Location {
+	if tz, ok := tzs[tzid]; ok {
+		naive, err := time.Parse(IcsFormatLocal, naiveValue)
+		if err == nil {
+			name, offset := tz.offsetFor(naive)
+			if seconds, ok := parseUTCOffset(offset); ok {
+				if name == "" {
+					name = tzid
+				}
+				return time.FixedZone(name, seconds)
+			}
+		}
+	}
+
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// parseUTCOffset parses a TZOFFSETTO/TZOFFSETFROM value like "+0200" or
+// "-0530" into a signed number of seconds east of UTC
+func parseUTCOffset(offset string) (int, bool) {
+	offset = strings.TrimSpace(offset)
+	if len(offset) < 5 {
+		return 0, false
+	}
+
+	sign := 1
+	if offset[0] == '-' {
+		sign = -1
+	}
+	if offset[0] == '+' || offset[0] == '-' {
+		offset = offset[1:]
+	}
+
+	hours, err := strconv.Atoi(offset[0:2])
+	if err != nil {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(offset[2:4])
+	if err != nil {
+		return 0, false
+	}
+
+	return sign * (hours*3600 + minutes*60), true
+}
@@ -0,0 +1,154 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+const euBerlinVTimezone = `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:DAYLIGHT
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+TZNAME:CEST
+DTSTART:19700329T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+DTSTART:19701025T030000
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:1
+DTSTART;TZID=Europe/Berlin:20240115T090000
+DTEND;TZID=Europe/Berlin:20240115T100000
+END:VEVENT
+END:VCALENDAR
+`
+
+func parseBerlinVTimezones(t *testing.T) map[string]*vtimezone {
+	t.Helper()
+	root := parseComponentTree(euBerlinVTimezone)
+	vcalendar := root.ChildrenOf("VCALENDAR")[0]
+	return parseVTimezoneComponents(vcalendar.ChildrenOf("VTIMEZONE"))
+}
+
+func TestParseVTimezoneComponents(t *testing.T) {
+	tzs := parseBerlinVTimezones(t)
+	tz, ok := tzs["Europe/Berlin"]
+	if !ok {
+		t.Fatal("Europe/Berlin not found")
+	}
+	if tz.standard == nil || tz.daylight == nil {
+		t.Fatal("expected both STANDARD and DAYLIGHT rules")
+	}
+	if tz.standard.offsetTo != "+0100" {
+		t.Errorf("standard offsetTo = %q, want +0100", tz.standard.offsetTo)
+	}
+	if tz.daylight.offsetTo != "+0200" {
+		t.Errorf("daylight offsetTo = %q, want +0200", tz.daylight.offsetTo)
+	}
+}
+
+func TestTzTransitionRuleTransitionIn(t *testing.T) {
+	tzs := parseBerlinVTimezones(t)
+	tz := tzs["Europe/Berlin"]
+
+	// last Sunday of March 2024 is the 31st
+	dst := tz.daylight.transitionIn(2024)
+	want := time.Date(2024, time.March, 31, 2, 0, 0, 0, time.UTC)
+	if !dst.Equal(want) {
+		t.Errorf("daylight transitionIn(2024) = %v, want %v", dst, want)
+	}
+
+	// last Sunday of October 2024 is the 27th
+	std := tz.standard.transitionIn(2024)
+	want = time.Date(2024, time.October, 27, 3, 0, 0, 0, time.UTC)
+	if !std.Equal(want) {
+		t.Errorf("standard transitionIn(2024) = %v, want %v", std, want)
+	}
+}
+
+func TestVTimezoneOffsetForWinterAndSummer(t *testing.T) {
+	tzs := parseBerlinVTimezones(t)
+	tz := tzs["Europe/Berlin"]
+
+	winter := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	if name, offset := tz.offsetFor(winter); name != "CET" || offset != "+0100" {
+		t.Errorf("winter offsetFor = (%s, %s), want (CET, +0100)", name, offset)
+	}
+
+	summer := time.Date(2024, time.July, 15, 9, 0, 0, 0, time.UTC)
+	if name, offset := tz.offsetFor(summer); name != "CEST" || offset != "+0200" {
+		t.Errorf("summer offsetFor = (%s, %s), want (CEST, +0200)", name, offset)
+	}
+}
+
+func TestResolveLocationUsesVTimezoneOffset(t *testing.T) {
+	tzs := parseBerlinVTimezones(t)
+
+	loc := resolveLocation("Europe/Berlin", tzs, "20240115T090000")
+	_, offset := time.Date(2024, time.January, 15, 9, 0, 0, 0, loc).Zone()
+	if offset != 3600 {
+		t.Errorf("resolved offset = %d seconds, want 3600 (CET)", offset)
+	}
+
+	loc = resolveLocation("Europe/Berlin", tzs, "20240715T090000")
+	_, offset = time.Date(2024, time.July, 15, 9, 0, 0, 0, loc).Zone()
+	if offset != 7200 {
+		t.Errorf("resolved offset = %d seconds, want 7200 (CEST)", offset)
+	}
+}
+
+func TestResolveLocationFallsBackToIANA(t *testing.T) {
+	loc := resolveLocation("America/New_York", map[string]*vtimezone{}, "20240115T090000")
+	if loc == time.UTC {
+		t.Skip("America/New_York zone data not available, fallback to UTC expected")
+	}
+	name, _ := time.Date(2024, time.January, 15, 9, 0, 0, 0, loc).Zone()
+	if name != "EST" {
+		t.Errorf("zone name = %q, want EST", name)
+	}
+}
+
+func TestParseUTCOffset(t *testing.T) {
+	cases := []struct {
+		in      string
+		seconds int
+		ok      bool
+	}{
+		{"+0200", 7200, true},
+		{"-0530", -19800, true},
+		{"+0000", 0, true},
+		{"bogus", 0, false},
+		{"", 0, false},
+	}
+
+	for _, c := range cases {
+		seconds, ok := parseUTCOffset(c.in)
+		if ok != c.ok || seconds != c.seconds {
+			t.Errorf("parseUTCOffset(%q) = (%d, %v), want (%d, %v)", c.in, seconds, ok, c.seconds, c.ok)
+		}
+	}
+}
+
+func TestEventStartHonoursEmbeddedVTimezone(t *testing.T) {
+	root := parseComponentTree(euBerlinVTimezone)
+	vcalendar := root.ChildrenOf("VCALENDAR")[0]
+	tzs := parseVTimezoneComponents(vcalendar.ChildrenOf("VTIMEZONE"))
+	eventData := vcalendar.ChildrenOf("VEVENT")[0]
+
+	start, floating := parseDateTimeProp(eventData, "DTSTART", tzs)
+	if floating {
+		t.Error("TZID-qualified DTSTART should not be floating")
+	}
+	_, offset := start.Zone()
+	if offset != 3600 {
+		t.Errorf("DTSTART offset = %d seconds, want 3600 (CET, winter)", offset)
+	}
+}
@@ -0,0 +1,9 @@
+package ics
+
+import "os"
+
+// checks if a file exists on the local filesystem
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}